@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"io"
+	"reflect"
+)
+
+// writeAST writes the given Go AST to w, serialized as JSON. Each node is
+// represented by an object with a "Node" member holding the node type (e.g.
+// "FuncDecl") and a member for each of its fields. Positions, scopes and
+// objects are elided.
+//
+// Example:
+//    {"Node": "Ident", "Name": "x"}
+func writeAST(w io.Writer, file *ast.File) error {
+	buf, err := json.MarshalIndent(jsonNode(reflect.ValueOf(file)), "", "\t")
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+	_, err = w.Write(buf)
+	return err
+}
+
+var (
+	// posType is the type of positions, which are elided.
+	posType = reflect.TypeOf(token.NoPos)
+	// tokenType is the type of tokens, which are represented by their string.
+	tokenType = reflect.TypeOf(token.ILLEGAL)
+	// objectType and scopeType are the types of the resolved objects and
+	// scopes, which are elided.
+	objectType = reflect.TypeOf((*ast.Object)(nil))
+	scopeType  = reflect.TypeOf((*ast.Scope)(nil))
+)
+
+// jsonNode returns a JSON representation of the provided value of a Go AST.
+func jsonNode(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonNode(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = jsonNode(v.Index(i))
+		}
+		return list
+	case reflect.Struct:
+		t := v.Type()
+		node := map[string]interface{}{"Node": t.Name()}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			switch {
+			case len(field.PkgPath) > 0:
+				// Skip unexported fields.
+			case field.Type == posType, field.Type == objectType, field.Type == scopeType:
+				// Skip positions, objects and scopes.
+			case field.Name == "Unresolved":
+				// Skip unresolved identifiers of files, which are duplicates.
+			default:
+				node[field.Name] = jsonNode(v.Field(i))
+			}
+		}
+		return node
+	}
+	if v.Type() == tokenType {
+		return v.Interface().(token.Token).String()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,261 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// parseAtomicRMWInst converts the provided LLVM IR atomicrmw instruction into
+// an equivalent Go AST node (an assignment statement with a call to the
+// sync/atomic package on the right-hand side). The result of the instruction is
+// the original value of the memory. The memory ordering is ignored, as the
+// operations of the sync/atomic package are sequentially consistent.
+//
+//    %old = atomicrmw add i32* %p, i32 %v seq_cst  ; _old := atomic.AddInt32(_p, _v) - _v
+//    %old = atomicrmw sub i32* %p, i32 %v seq_cst  ; _old := atomic.AddInt32(_p, -_v) + _v
+//    %old = atomicrmw and i32* %p, i32 %v seq_cst  ; _old := atomic.AndInt32(_p, _v)
+//    %old = atomicrmw or i32* %p, i32 %v seq_cst   ; _old := atomic.OrInt32(_p, _v)
+//    %old = atomicrmw xor i32* %p, i32 %v seq_cst  ; _old := func() int32 { ... }()
+//    %old = atomicrmw xchg i32* %p, i32 %v seq_cst ; _old := atomic.SwapInt32(_p, _v)
+//
+// Syntax:
+//    <result> = atomicrmw [volatile] <operation> <ty>* <pointer>, <ty> <value> [syncscope("<target-scope>")] <ordering>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#atomicrmw-instruction
+func parseAtomicRMWInst(inst llvm.Value) (ast.Stmt, error) {
+	// Locate the operation, which follows the "atomicrmw" keyword and the
+	// optional "volatile" keyword.
+	tokens, err := getTokens(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	var operation string
+	for i := 3; i < len(tokens); i++ {
+		if tokens[i].Val != "volatile" {
+			operation = tokens[i].Val
+			break
+		}
+	}
+
+	// Parse operands.
+	name, err := getAtomicTypeName(inst.Operand(1).Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	ptr, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	val, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+
+	typ, err := getGoType(inst.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	var old string
+	if operation == "xor" {
+		old = tempName(parentFunc(inst), "old")
+	}
+	expr, err := newAtomicRMW(operation, name, typ, ptr, val, old)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return &ast.AssignStmt{Lhs: []ast.Expr{result}, Tok: token.DEFINE, Rhs: []ast.Expr{expr}}, nil
+}
+
+// newAtomicRMW returns an expression which atomically applies the given
+// atomicrmw operation to the memory pointed to by ptr, and evaluates to the
+// original value of the memory. The sync/atomic package has no function of the
+// xor operation, which is translated into a compare-and-swap loop, where the
+// original value is stored in a local variable of the provided name.
+func newAtomicRMW(operation, name string, typ, ptr, val ast.Expr, old string) (ast.Expr, error) {
+	// The Add function of the sync/atomic package returns the new value of the
+	// memory, from which the original value is computed.
+	switch operation {
+	case "add":
+		//    atomic.AddInt32(_p, _v) - _v
+		call := newAtomicCall("Add"+name, ptr, val)
+		return &ast.BinaryExpr{X: call, Op: token.SUB, Y: val}, nil
+	case "sub":
+		//    atomic.AddInt32(_p, -_v) + _v
+		call := newAtomicCall("Add"+name, ptr, &ast.UnaryExpr{Op: token.SUB, X: val})
+		return &ast.BinaryExpr{X: call, Op: token.ADD, Y: val}, nil
+	case "and":
+		//    atomic.AndInt32(_p, _v)
+		return newAtomicCall("And"+name, ptr, val), nil
+	case "or":
+		//    atomic.OrInt32(_p, _v)
+		return newAtomicCall("Or"+name, ptr, val), nil
+	case "xor":
+		//    func() int32 {
+		//       for {
+		//          old := atomic.LoadInt32(_p)
+		//          if atomic.CompareAndSwapInt32(_p, old, old^_v) {
+		//             return old
+		//          }
+		//       }
+		//    }()
+		x := ast.NewIdent(old)
+		load := &ast.AssignStmt{Lhs: []ast.Expr{x}, Tok: token.DEFINE, Rhs: []ast.Expr{newAtomicCall("Load"+name, ptr)}}
+		ifStmt := &ast.IfStmt{
+			Cond: newAtomicCall("CompareAndSwap"+name, ptr, x, &ast.BinaryExpr{X: x, Op: token.XOR, Y: val}),
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{x}}}},
+		}
+		loop := &ast.ForStmt{Body: &ast.BlockStmt{List: []ast.Stmt{load, ifStmt}}}
+		fn := &ast.FuncLit{
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{loop}},
+		}
+		return &ast.CallExpr{Fun: fn}, nil
+	case "xchg":
+		//    atomic.SwapInt32(_p, _v)
+		return newAtomicCall("Swap"+name, ptr, val), nil
+	default:
+		return nil, errutil.Newf("support for atomicrmw operation %q not yet implemented", operation)
+	}
+}
+
+// parseCmpXchgInst converts the provided LLVM IR cmpxchg instruction into an
+// equivalent Go AST node (an assignment statement with a function literal call
+// on the right-hand side). The result of the instruction is the original value
+// of the memory and a boolean indicating success. The original value is loaded
+// after a failed exchange, which is approximate for concurrently modified
+// memory.
+//
+//    %r = cmpxchg i32* %p, i32 %cmp, i32 %new seq_cst seq_cst
+//
+//    _r := func() struct{ _0 int32; _1 bool } {
+//       if atomic.CompareAndSwapInt32(_p, _cmp, _new) {
+//          return struct{ _0 int32; _1 bool }{_cmp, true}
+//       }
+//       return struct{ _0 int32; _1 bool }{atomic.LoadInt32(_p), false}
+//    }()
+//
+// Syntax:
+//    <result> = cmpxchg [weak] [volatile] <ty>* <pointer>, <ty> <cmp>, <ty> <new> [syncscope("<target-scope>")] <success ordering> <failure ordering>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#cmpxchg-instruction
+func parseCmpXchgInst(inst llvm.Value) (ast.Stmt, error) {
+	typ, err := getGoType(inst.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	name, err := getAtomicTypeName(inst.Operand(1).Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Parse operands.
+	ptr, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	cmp, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	newVal, err := parseOperand(inst.Operand(2))
+	if err != nil {
+		return nil, err
+	}
+
+	expr := newCmpXchg(name, typ, ptr, cmp, newVal, inst.Type().TypeKind() == llvm.StructTypeKind)
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{expr}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// newCmpXchg returns an expression which atomically exchanges the memory pointed
+// to by ptr with newVal if it holds cmp, and evaluates to a value of the given
+// type. The value is a pair of the original value and a boolean indicating
+// success if pair is set, and the original value otherwise.
+func newCmpXchg(name string, typ, ptr, cmp, newVal ast.Expr, pair bool) ast.Expr {
+	// Results of the successful and the failed exchange. The result of cmpxchg
+	// is only the original value in LLVM IR versions prior to 3.5.
+	succ := ast.Expr(cmp)
+	fail := ast.Expr(newAtomicCall("Load"+name, ptr))
+	if pair {
+		succ = &ast.CompositeLit{Type: typ, Elts: []ast.Expr{cmp, newIdent("true")}}
+		fail = &ast.CompositeLit{Type: typ, Elts: []ast.Expr{fail, newIdent("false")}}
+	}
+
+	// Create function literal.
+	ifStmt := &ast.IfStmt{
+		Cond: newAtomicCall("CompareAndSwap"+name, ptr, cmp, newVal),
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{succ}}}},
+	}
+	fn := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{ifStmt, &ast.ReturnStmt{Results: []ast.Expr{fail}}}},
+	}
+	return &ast.CallExpr{Fun: fn}
+}
+
+// parseFenceInst converts the provided LLVM IR fence instruction into an
+// equivalent Go AST node. Go has no equivalent of explicit memory barriers, so
+// the instruction is dropped (nil is returned). If the Fences option is set,
+// the instruction is marked with a comment preceding the next statement.
+//
+//    fence seq_cst ; // fence seq_cst
+//
+// Syntax:
+//    fence [syncscope("<target-scope>")] <ordering>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#fence-instruction
+func parseFenceInst(inst llvm.Value) (ast.Stmt, error) {
+	if !getOptions(inst).Fences {
+		return nil, nil
+	}
+	s, err := hackDump(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	addPendingComment(inst, strings.TrimSpace(s))
+	return nil, nil
+}
+
+// getAtomicTypeName returns the type name used by the functions of the
+// sync/atomic package which operate on the provided LLVM IR type (e.g. "Int32"
+// of atomic.AddInt32 for i32).
+func getAtomicTypeName(typ llvm.Type) (string, error) {
+	if typ.TypeKind() == llvm.IntegerTypeKind {
+		switch typ.IntTypeWidth() {
+		case 32:
+			return "Int32", nil
+		case 64:
+			return "Int64", nil
+		}
+	}
+	return "", errutil.Newf("support for atomic operations on %v not yet implemented", typ)
+}
+
+// newAtomicCall returns a call to the given function of the sync/atomic
+// package.
+func newAtomicCall(name string, args ...ast.Expr) *ast.CallExpr {
+	fun := newPkgSelector("sync/atomic", name)
+	return &ast.CallExpr{Fun: fun, Args: args}
+}
@@ -0,0 +1,85 @@
+package decomp
+
+import (
+	"go/ast"
+	"testing"
+)
+
+func TestNewAtomicRMW(t *testing.T) {
+	golden := []struct {
+		operation string
+		want      string
+		err       bool
+	}{
+		{operation: "add", want: "atomic.AddInt32(_p, _v) - _v"},
+		{operation: "sub", want: "atomic.AddInt32(_p, -_v) + _v"},
+		{operation: "and", want: "atomic.AndInt32(_p, _v)"},
+		{operation: "or", want: "atomic.OrInt32(_p, _v)"},
+		{
+			operation: "xor",
+			want: `func() int32 {
+	for {
+		old_tmp := atomic.LoadInt32(_p)
+		if atomic.CompareAndSwapInt32(_p, old_tmp, old_tmp^_v) {
+			return old_tmp
+		}
+	}
+}()`,
+		},
+		{operation: "xchg", want: "atomic.SwapInt32(_p, _v)"},
+		{operation: "nand", err: true},
+	}
+	for _, g := range golden {
+		typ, ptr, val := ast.NewIdent("int32"), ast.NewIdent("_p"), ast.NewIdent("_v")
+		expr, err := newAtomicRMW(g.operation, "Int32", typ, ptr, val, "old_tmp")
+		if g.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", g.operation)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", g.operation, err)
+			continue
+		}
+		if got := sprintNode(expr); got != g.want {
+			t.Errorf("%s: expression mismatch; expected %q, got %q", g.operation, g.want, got)
+		}
+	}
+}
+
+func TestNewCmpXchg(t *testing.T) {
+	golden := []struct {
+		typ  ast.Expr
+		pair bool
+		want string
+	}{
+		{
+			typ:  ast.NewIdent("int64"),
+			pair: false,
+			want: `func() int64 {
+	if atomic.CompareAndSwapInt64(_p, _cmp, _new) {
+		return _cmp
+	}
+	return atomic.LoadInt64(_p)
+}()`,
+		},
+		{
+			typ:  ast.NewIdent("T"),
+			pair: true,
+			want: `func() T {
+	if atomic.CompareAndSwapInt64(_p, _cmp, _new) {
+		return T{_cmp, true}
+	}
+	return T{atomic.LoadInt64(_p), false}
+}()`,
+		},
+	}
+	for _, g := range golden {
+		ptr, cmp, newVal := ast.NewIdent("_p"), ast.NewIdent("_cmp"), ast.NewIdent("_new")
+		expr := newCmpXchg("Int64", g.typ, ptr, cmp, newVal, g.pair)
+		if got := sprintNode(expr); got != g.want {
+			t.Errorf("pair=%v: expression mismatch; expected %q, got %q", g.pair, g.want, got)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// BasicBlock represents a conceptual basic block. If one statement of the basic
+// block is executed all statements of the basic block are executed until the
+// terminating instruction is reached which transfers control to another basic
+// block.
+type BasicBlock interface {
+	// Name returns the name of the basic block.
+	Name() string
+	// Stmts returns the statements of the basic block.
+	Stmts() []ast.Stmt
+	// SetStmts sets the statements of the basic block.
+	SetStmts(stmts []ast.Stmt)
+	// Term returns the terminator instruction of the basic block.
+	Term() llvm.Value
+	// SetTerm sets the terminator instruction of the basic block.
+	SetTerm(term llvm.Value)
+}
+
+// blockData holds the name, statements and terminator instruction shared by
+// the concrete implementations of the BasicBlock interface.
+type blockData struct {
+	// Basic block name.
+	name string
+	// Basic block statements.
+	stmts []ast.Stmt
+	// Terminator instruction.
+	term llvm.Value
+}
+
+// Name returns the name of the basic block.
+func (b *blockData) Name() string { return b.name }
+
+// Stmts returns the statements of the basic block.
+func (b *blockData) Stmts() []ast.Stmt { return b.stmts }
+
+// SetStmts sets the statements of the basic block.
+func (b *blockData) SetStmts(stmts []ast.Stmt) { b.stmts = stmts }
+
+// Term returns the terminator instruction of the basic block.
+func (b *blockData) Term() llvm.Value { return b.term }
+
+// SetTerm sets the terminator instruction of the basic block.
+func (b *blockData) SetTerm(term llvm.Value) { b.term = term }
+
+// basicBlock represents a basic block in which the instructions have been
+// translated to Go AST statement nodes but the terminator instruction is an
+// unmodified LLVM IR value.
+type basicBlock struct {
+	blockData
+	// A map from variable name to variable definitions which represents the PHI
+	// instructions of the basic block.
+	phis map[string][]*definition
+}
+
+// parseBasicBlock converts the provided LLVM IR basic block into a basic block
+// in which the instructions have been translated to Go AST statement nodes but
+// the terminator instruction is an unmodified LLVM IR value.
+func parseBasicBlock(llBB llvm.BasicBlock) (bb *basicBlock, err error) {
+	name, err := getBBName(llBB.AsValue())
+	if err != nil {
+		return nil, err
+	}
+	bb = &basicBlock{blockData: blockData{name: name}, phis: make(map[string][]*definition)}
+	for inst := llBB.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+		// Handle terminator instruction. Basic blocks which consist solely of a
+		// terminator instruction (e.g. "br label %next") have no statements.
+		if inst == llBB.LastInstruction() {
+			err = bb.addTerm(inst)
+			if err != nil {
+				return nil, blockError(name, inst, err)
+			}
+			return bb, nil
+		}
+
+		// Handle PHI instructions.
+		if inst.InstructionOpcode() == llvm.PHI {
+			ident, def, err := parsePHIInst(inst)
+			if err != nil {
+				return nil, blockError(name, inst, err)
+			}
+			bb.phis[ident] = def
+			continue
+		}
+
+		// Handle non-terminator instructions.
+		stmt, err := parseInst(inst)
+		if err != nil {
+			return nil, blockError(name, inst, err)
+		}
+		if stmt == nil {
+			// The instruction has no Go equivalent (e.g. llvm.va_start).
+			continue
+		}
+		err = addComment(stmt, inst)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		bb.stmts = append(bb.stmts, stmt)
+	}
+	return nil, blockError(name, llvm.Value{}, errutil.New("invalid basic block; contains no instructions"))
+}
+
+// addTerm adds the provided terminator instruction to the basic block. If the
+// terminator instruction doesn't have a target basic block (e.g. ret) it is
+// parsed and added to the statements list of the basic block instead.
+func (bb *basicBlock) addTerm(term llvm.Value) error {
+	switch opcode := term.InstructionOpcode(); opcode {
+	case llvm.Ret:
+		// The return instruction doesn't have any target basic blocks so treat it
+		// like a regular instruction and append it to the list of statements.
+		ret, err := parseInst(term)
+		if err != nil {
+			return err
+		}
+		if stmt, ok := ret.(*ast.ReturnStmt); ok && len(stmt.Results) == 1 && isReturnedCmp(term) {
+			// Fold comparisons which are only used by the return statement.
+			//
+			//    // from:
+			//    _c := x > 0
+			//    return _c
+			//
+			//    // to:
+			//    return x > 0
+			stmt.Results[0] = expandCond(bb, stmt.Results[0])
+		}
+		err = addComment(ret, term)
+		if err != nil {
+			return errutil.Err(err)
+		}
+		bb.stmts = append(bb.stmts, ret)
+	case llvm.Unreachable:
+		// The unreachable instruction doesn't have any target basic blocks
+		// either, and is translated into a call to panic.
+		//
+		//    panic("unreachable")
+		bb.stmts = append(bb.stmts, newUnreachable())
+	case llvm.Br, llvm.Switch, llvm.IndirectBr, llvm.Invoke:
+		if ignoresUnwind(term) {
+			// The call of an invoke instruction is added to the list of
+			// statements, while the terminator is treated as an unconditional
+			// branch to the normal destination.
+			//
+			//    %r = invoke i32 @foo() to label %1 unwind label %2 ; _r := foo()
+			call, err := parseCallInst(term)
+			if err != nil {
+				return err
+			}
+			err = addComment(call, term)
+			if err != nil {
+				return errutil.Err(err)
+			}
+			bb.stmts = append(bb.stmts, call)
+		}
+		// Parse the terminator instruction during the control flow analysis.
+		bb.term = term
+	case llvm.Resume:
+		return newEHError(term)
+	default:
+		return errutil.Newf("non-terminator instruction %q at end of basic block", prettyOpcode(opcode))
+	}
+	return nil
+}
+
+// newUnreachable returns a new statement which panics if reached.
+func newUnreachable() ast.Stmt {
+	call := &ast.CallExpr{
+		Fun:  newIdent("panic"),
+		Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"unreachable"`}},
+	}
+	return &ast.ExprStmt{X: call}
+}
+
+// lowerPHIs replaces the PHI instructions of each basic block with assignment
+// statements in the corresponding predecessor basic blocks. The assignments are
+// inserted before the terminator of each predecessor, as control is transferred
+// to the PHI instruction's basic block by the terminator.
+//
+//    // from:
+//    1:
+//       br label %3
+//    3:
+//       %x = phi i32 [ 42, %1 ], ...
+//
+//    // to:
+//    1:
+//       x = 42
+//       br label %3
+//
+// The PHI instructions of a basic block are evaluated simultaneously, so the
+// assignments of each predecessor are ordered to read every identifier before
+// it is overwritten (see orderPHICopies).
+func lowerPHIs(llFunc llvm.Value, bbs map[string]BasicBlock) error {
+	// Sort basic block names, identifiers and definitions to produce
+	// deterministic output.
+	var bbNames []string
+	for bbName := range bbs {
+		bbNames = append(bbNames, bbName)
+	}
+	sort.Strings(bbNames)
+	for _, bbName := range bbNames {
+		bb := bbs[bbName]
+		block, ok := bb.(*basicBlock)
+		if !ok {
+			return errutil.Newf("invalid basic block type; expected *basicBlock, got %T", bb)
+		}
+		var idents []string
+		for ident := range block.phis {
+			idents = append(idents, ident)
+		}
+		sort.Strings(idents)
+
+		// Group the copies of the PHI instructions by predecessor.
+		copies := make(map[string][]*phiCopy)
+		var predNames []string
+		for _, ident := range idents {
+			defs := block.phis[ident]
+			sort.Sort(definitionsByBB(defs))
+			for _, def := range defs {
+				if _, ok := bbs[def.bb]; !ok {
+					if getOptions(llFunc).IgnoreUnwind {
+						// The predecessor is only reachable through an ignored
+						// unwind destination.
+						continue
+					}
+					return errutil.Newf("unable to locate predecessor basic block %q of PHI instruction %q", def.bb, ident)
+				}
+				if _, ok := copies[def.bb]; !ok {
+					predNames = append(predNames, def.bb)
+				}
+				copies[def.bb] = append(copies[def.bb], &phiCopy{ident: ident, expr: def.expr})
+			}
+		}
+		sort.Strings(predNames)
+		for _, predName := range predNames {
+			pred := bbs[predName]
+			for _, stmt := range orderPHICopies(llFunc, copies[predName]) {
+				pred.SetStmts(insertBeforeTerm(pred.Stmts(), stmt))
+			}
+		}
+	}
+	return nil
+}
+
+// A phiCopy represents the assignment of an incoming value to the identifier of
+// a PHI instruction.
+type phiCopy struct {
+	// Identifier of the PHI instruction.
+	ident string
+	// Incoming value.
+	expr ast.Expr
+}
+
+// orderPHICopies returns assignment statements which perform the provided
+// simultaneous copies in sequence. A copy is only performed once its
+// destination is no longer read by any other pending copy. Cyclic dependencies
+// (e.g. swapping two variables) are broken by saving the destination of a copy
+// in a temporary variable.
+//
+//    // from:
+//    %x = phi i32 [ %y, %2 ], ...
+//    %y = phi i32 [ %x, %2 ], ...
+//
+//    // to:
+//    x_tmp := x
+//    x = y
+//    y = x_tmp
+func orderPHICopies(llFunc llvm.Value, copies []*phiCopy) []ast.Stmt {
+	var stmts []ast.Stmt
+	for len(copies) > 0 {
+		i := readyPHICopy(copies)
+		if i == -1 {
+			// Every pending destination is read by another copy; save the
+			// destination of the first copy in a temporary variable.
+			ident := copies[0].ident
+			tmp := tempName(llFunc, ident)
+			save := &ast.AssignStmt{
+				Lhs: []ast.Expr{newIdent(tmp)},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{newIdent(ident)},
+			}
+			stmts = append(stmts, save)
+			for _, c := range copies[1:] {
+				renameIdent(c.expr, ident, tmp)
+			}
+			continue
+		}
+		c := copies[i]
+		assign := &ast.AssignStmt{
+			Lhs: []ast.Expr{newIdent(c.ident)},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{c.expr},
+		}
+		stmts = append(stmts, assign)
+		copies = append(copies[:i], copies[i+1:]...)
+	}
+	return stmts
+}
+
+// readyPHICopy returns the index of the first copy whose destination is not
+// read by any other copy, or -1 if no such copy exists.
+func readyPHICopy(copies []*phiCopy) int {
+	for i, c := range copies {
+		ready := true
+		for j, other := range copies {
+			if i != j && readsIdent(other.expr, c.ident) {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			return i
+		}
+	}
+	return -1
+}
+
+// readsIdent returns true if the provided expression reads the given
+// identifier, and false otherwise.
+func readsIdent(expr ast.Expr, name string) bool {
+	found := false
+	inspectIdents(expr, func(ident *ast.Ident) {
+		if ident.Name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// renameIdent replaces each use of the given identifier within the provided
+// expression with the new identifier.
+func renameIdent(expr ast.Expr, name, newName string) {
+	inspectIdents(expr, func(ident *ast.Ident) {
+		if ident.Name == name {
+			ident.Name = newName
+		}
+	})
+}
+
+// inspectIdents invokes f for each identifier referring to a variable within
+// the provided expression. Field names of selector expressions are skipped.
+func inspectIdents(expr ast.Expr, f func(ident *ast.Ident)) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			f(n)
+		case *ast.SelectorExpr:
+			inspectIdents(n.X, f)
+			return false
+		}
+		return true
+	})
+}
+
+// definitionsByBB implements sort.Interface, sorting definitions by the name of
+// their source basic block.
+type definitionsByBB []*definition
+
+func (defs definitionsByBB) Len() int           { return len(defs) }
+func (defs definitionsByBB) Less(i, j int) bool { return defs[i].bb < defs[j].bb }
+func (defs definitionsByBB) Swap(i, j int)      { defs[i], defs[j] = defs[j], defs[i] }
+
+// insertBeforeTerm inserts the statement at the end of the statement list, but
+// before any return statement originating from a terminator instruction.
+func insertBeforeTerm(stmts []ast.Stmt, stmt ast.Stmt) []ast.Stmt {
+	n := len(stmts)
+	if n > 0 {
+		if _, ok := stmts[n-1].(*ast.ReturnStmt); ok {
+			stmts = append(stmts[:n-1], stmt, stmts[n-1])
+			return stmts
+		}
+	}
+	return append(stmts, stmt)
+}
@@ -0,0 +1,102 @@
+package decomp
+
+import (
+	"go/ast"
+	"strconv"
+
+	"github.com/mewfork/dot"
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// createCFG creates a control flow graph for the provided LLVM IR function,
+// which contains one node per basic block and one directed edge per branch
+// target. The entry node is labeled "entry", and the edges of conditional
+// branches and switch terminators are labeled with their branch condition.
+//
+// Example:
+//    digraph foo {
+//       0 [label="entry"]
+//       0->1 [label="true"]
+//       0->2 [label="false"]
+//       1->2
+//       2
+//    }
+func createCFG(llFunc llvm.Value) (*dot.Graph, error) {
+	graph := dot.NewGraph()
+	graph.SetDir(true)
+	graph.SetName(llFunc.Name())
+
+	for i, llBB := range funcBlocks(llFunc) {
+		// Add node (i.e. basic block) to the graph.
+		bbName, err := getBBName(llBB.AsValue())
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		attrs := dot.NewAttrs()
+		if i == 0 {
+			// Label the entry basic block.
+			attrs["label"] = "entry"
+		}
+		graph.AddNode(llFunc.Name(), bbName, attrs)
+
+		// Add edges from node (i.e. target basic blocks) to the graph.
+		term := llBB.LastInstruction()
+		if term.IsNil() {
+			return nil, errutil.Newf("unable to locate terminator of basic block %q", bbName)
+		}
+		labels, err := getEdgeLabels(term)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		for j, succ := range getSuccessors(term) {
+			target, err := getBBName(succ.AsValue())
+			if err != nil {
+				return nil, errutil.Err(err)
+			}
+			attrs := dot.NewAttrs()
+			if labels != nil {
+				attrs["label"] = labels[j]
+			}
+			graph.AddEdge(bbName, target, true, attrs)
+		}
+	}
+
+	return graph, nil
+}
+
+// getEdgeLabels returns the edge labels of each successor of the provided
+// terminator instruction, or nil if the edges are unlabeled.
+//
+// Syntax:
+//    br i1 <cond>, label <target_true>, label <target_false>
+//    switch <intty> <value>, label <default> [ <intty> <val>, label <dest> ... ]
+func getEdgeLabels(term llvm.Value) ([]string, error) {
+	switch opcode := term.InstructionOpcode(); opcode {
+	case llvm.Br:
+		if term.SuccessorsCount() != 2 {
+			// Unconditional branch.
+			return nil, nil
+		}
+		// The successors of conditional branches are ordered as the true target
+		// followed by the false target.
+		return []string{`"true"`, `"false"`}, nil
+	case llvm.Switch:
+		_, _, cases, err := getSwitchCases(term)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		// The first successor of switch terminators is the default target,
+		// followed by the target of each case.
+		labels := []string{`"default"`}
+		for _, c := range cases {
+			lit, ok := c.val.(*ast.BasicLit)
+			if !ok {
+				return nil, errutil.Newf("invalid switch case value; expected *ast.BasicLit, got %T", c.val)
+			}
+			labels = append(labels, strconv.Quote(lit.Value))
+		}
+		return labels, nil
+	}
+	return nil, nil
+}
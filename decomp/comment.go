@@ -0,0 +1,285 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// A commentMap maps from generated statements to the comment lines which
+// precede them.
+//
+// Comments of the go/ast package are positioned relative to the source code
+// they accompany, which the generated AST nodes lack. The comment lines are
+// therefore recorded separately, and positioned once the Go source file is
+// complete (see positionComments).
+type commentMap map[ast.Stmt][]string
+
+// addComment records the comment lines which precede the provided statement,
+// which is translated from the given LLVM IR instruction. The pending comment
+// lines of the function (e.g. fence instructions) are recorded first, followed
+// by a note of each undef or poison operand, which is lowered to the zero value
+// of its type. The LLVM IR instruction itself is recorded if the Comments
+// option is set.
+//
+// Example:
+//    // zero value of i32 undef
+//    // from: %3 = add nsw i32 %x, undef
+//    _3 := x + 0
+func addComment(stmt ast.Stmt, inst llvm.Value) error {
+	opts := getOptions(inst)
+	if opts.comments == nil {
+		return nil
+	}
+	ctx := getContext(inst)
+	if ctx == nil {
+		return nil
+	}
+	lines := ctx.pending
+	ctx.pending = nil
+	for i := 0; i < inst.OperandsCount(); i++ {
+		op := inst.Operand(i)
+		if op.IsAUndefValue().IsNil() {
+			continue
+		}
+		s, err := hackDump(op)
+		if err != nil {
+			return errutil.Err(err)
+		}
+		lines = append(lines, "zero value of "+strings.TrimSpace(s))
+	}
+	if opts.Comments {
+		s, err := hackDump(inst)
+		if err != nil {
+			return errutil.Err(err)
+		}
+		lines = append(lines, "from: "+strings.TrimSpace(s))
+	}
+	if len(lines) > 0 {
+		opts.comments[stmt] = append(opts.comments[stmt], lines...)
+	}
+	return nil
+}
+
+// addPendingComment records a comment line of the function containing the
+// provided LLVM IR instruction, which has no Go equivalent. The comment line
+// precedes the next statement of the function.
+//
+// Example:
+//    // fence seq_cst
+func addPendingComment(inst llvm.Value, text string) {
+	if getOptions(inst).comments == nil {
+		return
+	}
+	if ctx := getContext(inst); ctx != nil {
+		ctx.pending = append(ctx.pending, text)
+	}
+}
+
+// moveComments moves the recorded comment lines of a statement, which is
+// removed or replaced by a pass, to the given statement; preceding its own
+// comment lines.
+func moveComments(comments commentMap, from, to ast.Stmt) {
+	lines, ok := comments[from]
+	if !ok {
+		return
+	}
+	delete(comments, from)
+	comments[to] = append(lines, comments[to]...)
+}
+
+// commentGap specifies the distance between the offsets of consecutive lines of
+// positioned Go source files. The go/printer package places a comment before
+// the first token whose offset exceeds the offset of the comment, and estimates
+// the offsets of unpositioned tokens by the length of their output; which
+// therefore must not exceed the gap between lines.
+const commentGap = 1 << 24
+
+// positionComments positions the statements of the provided Go source file,
+// and adds the recorded comment lines of the statements to the file, positioned
+// on separate lines before their statements. The positions are added to the
+// given file set, which must be used to print the Go source file.
+//
+// Each statement and each declaration is positioned on a line of its own,
+// which is the line of its first token, and declarations are separated by an
+// empty line. The remaining tokens are unpositioned, and are laid out by the
+// printer as before.
+func positionComments(fset *token.FileSet, file *ast.File, comments commentMap) {
+	if len(comments) == 0 {
+		return
+	}
+	p := &positioner{comments: comments}
+	for i, decl := range file.Decls {
+		if i > 0 {
+			// Declarations are separated by an empty line.
+			p.newLine()
+		}
+		if f, ok := decl.(*ast.FuncDecl); ok && f.Body != nil {
+			// The opening brace of the function body is positioned on the line of
+			// the function declaration, which is followed by the lines of the
+			// function body.
+			//
+			//    func f() {
+			f.Type = copyNode(f.Type).(*ast.FuncType)
+			f.Type.Func = p.newLine()
+			f.Body.Lbrace = f.Type.Func + 1
+			p.fields = append(p.fields, &f.Type.Func, &f.Body.Lbrace)
+			p.positionBlock(f.Body)
+			continue
+		}
+		p.positionFirst(reflect.ValueOf(file.Decls).Index(i), p.newLine())
+		p.positionChildren(file.Decls[i])
+	}
+
+	// Convert the line offsets to positions of the file set.
+	tf := fset.AddFile("", fset.Base()+1, len(p.lines)*commentGap)
+	tf.SetLines(p.lines)
+	done := make(map[*token.Pos]bool)
+	for _, pos := range p.fields {
+		if !done[pos] {
+			*pos = tf.Pos(int(*pos - 1))
+			done[pos] = true
+		}
+	}
+	for _, c := range p.cgs {
+		c.List[0].Slash = tf.Pos(int(c.List[0].Slash - 1))
+	}
+	file.Comments = append(file.Comments, p.cgs...)
+	sort.Slice(file.Comments, func(i, j int) bool {
+		return file.Comments[i].Pos() < file.Comments[j].Pos()
+	})
+}
+
+// posType is the type of positions.
+var posType = reflect.TypeOf(token.NoPos)
+
+// A positioner positions the statements of a Go source file.
+//
+// Positions are recorded as line offsets plus one until the file is complete,
+// as the base of the file within the file set depends on its size.
+type positioner struct {
+	// Recorded comment lines of statements.
+	comments commentMap
+	// Offsets of the lines of the file.
+	lines []int
+	// Comment groups of the file.
+	cgs []*ast.CommentGroup
+	// Positioned fields of the nodes of the file.
+	fields []*token.Pos
+}
+
+// newLine adds a line to the file and returns its offset plus one.
+func (p *positioner) newLine() token.Pos {
+	off := len(p.lines) * commentGap
+	p.lines = append(p.lines, off)
+	return token.Pos(off + 1)
+}
+
+// positionBlock positions the statements of the provided block statement, and
+// its closing brace on a line of its own.
+func (p *positioner) positionBlock(block *ast.BlockStmt) {
+	block.List = p.positionStmts(block.List)
+	block.Rbrace = p.newLine()
+	p.fields = append(p.fields, &block.Rbrace)
+}
+
+// positionStmts positions the provided statements and their recorded comment
+// lines, and returns the positioned statements. Empty statements are not
+// printed, and are therefore not positioned.
+func (p *positioner) positionStmts(stmts []ast.Stmt) []ast.Stmt {
+	for i := range stmts {
+		for _, text := range p.comments[stmts[i]] {
+			text = strings.Replace(text, "\n", " ", -1)
+			c := &ast.Comment{Slash: p.newLine(), Text: "// " + text}
+			p.cgs = append(p.cgs, &ast.CommentGroup{List: []*ast.Comment{c}})
+		}
+		if _, ok := stmts[i].(*ast.EmptyStmt); ok {
+			continue
+		}
+		p.positionFirst(reflect.ValueOf(stmts).Index(i), p.newLine())
+		p.positionChildren(stmts[i])
+	}
+	return stmts
+}
+
+// positionChildren positions the statements nested within the provided node.
+func (p *positioner) positionChildren(n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			p.positionBlock(n)
+			return false
+		case *ast.CaseClause:
+			n.Body = p.positionStmts(n.Body)
+			return false
+		}
+		return true
+	})
+}
+
+// positionFirst sets the position of the first token of the node held by v,
+// which is either a node or a position. The nodes along the path to the first
+// token are copied, as nodes may be shared between statements (e.g. by
+// expression propagation). The boolean return value indicates success.
+func (p *positioner) positionFirst(v reflect.Value, pos token.Pos) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return false
+		}
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		if !p.positionFirst(elem, pos) {
+			return false
+		}
+		v.Set(elem)
+		return true
+	case reflect.Ptr:
+		if v.IsNil() || v.Type() == reflect.TypeOf((*ast.CommentGroup)(nil)) || v.Type() == reflect.TypeOf((*ast.Object)(nil)) {
+			return false
+		}
+		elem := reflect.New(v.Type().Elem())
+		elem.Elem().Set(v.Elem())
+		if !p.positionFirst(elem.Elem(), pos) {
+			return false
+		}
+		v.Set(elem)
+		return true
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return false
+		}
+		list := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(list, v)
+		if !p.positionFirst(list.Index(0), pos) {
+			return false
+		}
+		v.Set(list)
+		return true
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if p.positionFirst(v.Field(i), pos) {
+				return true
+			}
+		}
+	default:
+		if v.Type() == posType {
+			v.SetInt(int64(pos))
+			p.fields = append(p.fields, v.Addr().Interface().(*token.Pos))
+			return true
+		}
+	}
+	return false
+}
+
+// copyNode returns a shallow copy of the provided node.
+func copyNode(n ast.Node) ast.Node {
+	v := reflect.New(reflect.TypeOf(n).Elem())
+	v.Elem().Set(reflect.ValueOf(n).Elem())
+	return v.Interface().(ast.Node)
+}
@@ -0,0 +1,73 @@
+package decomp
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"testing"
+)
+
+func TestPositionComments(t *testing.T) {
+	// x := 1
+	def := &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent("x")}, Tok: token.DEFINE, Rhs: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: "1"}}}
+	// f(x)
+	call := &ast.ExprStmt{X: &ast.CallExpr{Fun: ast.NewIdent("f"), Args: []ast.Expr{ast.NewIdent("x")}}}
+	// return
+	ret := &ast.ReturnStmt{}
+	ifStmt := &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: ast.NewIdent("x"), Op: token.LSS, Y: &ast.BasicLit{Kind: token.INT, Value: "2"}},
+		Body: &ast.BlockStmt{List: []ast.Stmt{call, ret}},
+	}
+	file := &ast.File{
+		Name: ast.NewIdent("p"),
+		Decls: []ast.Decl{
+			&ast.FuncDecl{
+				Name: ast.NewIdent("g"),
+				Type: &ast.FuncType{Params: &ast.FieldList{}},
+				Body: &ast.BlockStmt{List: []ast.Stmt{def, ifStmt, &ast.EmptyStmt{}, call}},
+			},
+			&ast.FuncDecl{
+				Name: ast.NewIdent("h"),
+				Type: &ast.FuncType{Params: &ast.FieldList{}},
+				Body: &ast.BlockStmt{List: []ast.Stmt{ret}},
+			},
+		},
+	}
+	comments := commentMap{
+		def:  {"from: %x = add i32 0, 1"},
+		call: {"fence seq_cst", "from: call void @f(i32 %x)"},
+		ret:  {"from: ret void"},
+	}
+	fset := token.NewFileSet()
+	positionComments(fset, file, comments)
+	buf := new(bytes.Buffer)
+	if err := format.Node(buf, fset, file); err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+func g() {
+	// from: %x = add i32 0, 1
+	x := 1
+	if x < 2 {
+		// fence seq_cst
+		// from: call void @f(i32 %x)
+		f(x)
+		// from: ret void
+		return
+	}
+	// fence seq_cst
+	// from: call void @f(i32 %x)
+	f(x)
+}
+
+func h() {
+	// from: ret void
+	return
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+}
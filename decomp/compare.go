@@ -0,0 +1,93 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// normalizeCmps rewrites the comparisons of the function body which have a
+// literal as their left operand and a non-literal as their right operand, to
+// place the literal on the right. The comparison operator is mirrored to
+// preserve the semantics.
+//
+//    10 > i   ; i < 10
+//    0 <= x   ; x >= 0
+//    nil == p ; p == nil
+func normalizeCmps(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		expr, ok := n.(*ast.BinaryExpr)
+		if !ok || !isLit(expr.X) || isLit(expr.Y) {
+			return true
+		}
+		if op, ok := mirrorCmp(expr.Op); ok {
+			expr.X, expr.Y = expr.Y, expr.X
+			expr.Op = op
+		}
+		return true
+	})
+}
+
+// mirrorCmp returns the comparison operator which gives the same result as op
+// when its operands are swapped, and a boolean indicating whether op is a
+// comparison operator.
+func mirrorCmp(op token.Token) (token.Token, bool) {
+	switch op {
+	case token.EQL, token.NEQ:
+		return op, true
+	case token.LSS:
+		return token.GTR, true
+	case token.GTR:
+		return token.LSS, true
+	case token.LEQ:
+		return token.GEQ, true
+	case token.GEQ:
+		return token.LEQ, true
+	}
+	return op, false
+}
+
+// isLit returns true if the provided expression is a literal (e.g. 42, "foo" or
+// nil), and false otherwise.
+func isLit(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		return expr.Name == "nil"
+	}
+	return false
+}
+
+// simplifyBoolCmps rewrites the comparisons of the function body against the
+// boolean constants true and false, into the boolean operand or its negation.
+//
+//    x == true  ; x
+//    x != false ; x
+//    x == false ; !x
+//    x != true  ; !x
+func simplifyBoolCmps(body *ast.BlockStmt) {
+	rewriteExprs(body, func(expr ast.Expr) ast.Expr {
+		cmp, ok := expr.(*ast.BinaryExpr)
+		if !ok || (cmp.Op != token.EQL && cmp.Op != token.NEQ) {
+			return expr
+		}
+		x, val := cmp.X, cmp.Y
+		if isBoolLit(x) {
+			x, val = val, x
+		}
+		if !isBoolLit(val) || isBoolLit(x) {
+			return expr
+		}
+		if (val.(*ast.Ident).Name == "true") == (cmp.Op == token.EQL) {
+			return x
+		}
+		return negateCond(x)
+	})
+}
+
+// isBoolLit returns true if the provided expression is one of the boolean
+// constants true and false, and false otherwise.
+func isBoolLit(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && (ident.Name == "true" || ident.Name == "false")
+}
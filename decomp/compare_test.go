@@ -0,0 +1,95 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+)
+
+func TestNormalizeCmps(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		{src: "func f(i int32) bool { return 10 > i }", want: "i < 10"},
+		{src: "func f(x int32) bool { return 0 <= x }", want: "x >= 0"},
+		{src: "func f(i int32) bool { return 10 < i }", want: "i > 10"},
+		{src: "func f(x int32) bool { return 0 >= x }", want: "x <= 0"},
+		{src: "func f(p *int32) bool { return nil == p }", want: "p == nil"},
+		{src: "func f(s string) bool { return \"foo\" != s }", want: "s != \"foo\""},
+		// Nested comparisons.
+		{src: "func f(i, j int32) bool { return 0 < i && 10 > j }", want: "i > 0 && j < 10"},
+		// Non-comparisons, and comparisons of literals or non-literals.
+		{src: "func f(i int32) int32 { return 1 - i }", want: "1 - i"},
+		{src: "func f() bool { return 1 < 2 }", want: "1 < 2"},
+		{src: "func f(i, j int32) bool { return i > j }", want: "i > j"},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		normalizeCmps(f.Body)
+		if got := sprintNode(f.Body.List[0].(*ast.ReturnStmt).Results[0]); got != g.want {
+			t.Errorf("i=%d: expression mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+func TestMirrorCmp(t *testing.T) {
+	golden := []struct {
+		op   token.Token
+		want token.Token
+		ok   bool
+	}{
+		{op: token.EQL, want: token.EQL, ok: true},
+		{op: token.NEQ, want: token.NEQ, ok: true},
+		{op: token.LSS, want: token.GTR, ok: true},
+		{op: token.GTR, want: token.LSS, ok: true},
+		{op: token.LEQ, want: token.GEQ, ok: true},
+		{op: token.GEQ, want: token.LEQ, ok: true},
+		{op: token.SUB, want: token.SUB, ok: false},
+		{op: token.LAND, want: token.LAND, ok: false},
+	}
+	for i, g := range golden {
+		got, ok := mirrorCmp(g.op)
+		if got != g.want || ok != g.ok {
+			t.Errorf("i=%d: mirrored operator of %v mismatch; expected %v (%v), got %v (%v)", i, g.op, g.want, g.ok, got, ok)
+		}
+	}
+}
+
+func TestSimplifyBoolCmps(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		{src: "func f(c bool) bool { return c == true }", want: "c"},
+		{src: "func f(c bool) bool { return c != false }", want: "c"},
+		{src: "func f(c bool) bool { return c == false }", want: "!c"},
+		{src: "func f(c bool) bool { return c != true }", want: "!c"},
+		{src: "func f(c bool) bool { return true == c }", want: "c"},
+		// Comparisons are parenthesized when negated.
+		{src: "func f(x, y int32) bool { return x < y == false }", want: "!(x < y)"},
+		// Negations are removed when negated.
+		{src: "func f(c bool) bool { return !c == false }", want: "c"},
+		// Nested and chained comparisons.
+		{src: "func f(c bool) bool { return (c == true) == false }", want: "!c"},
+		{src: "func f(c, d bool) bool { return c == true && d != true }", want: "c && !d"},
+		// Comparisons of constants, and of non-constant operands.
+		{src: "func f() bool { return true == false }", want: "true == false"},
+		{src: "func f(c, d bool) bool { return c == d }", want: "c == d"},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		simplifyBoolCmps(f.Body)
+		if got := sprintNode(f.Body.List[0].(*ast.ReturnStmt).Results[0]); got != g.want {
+			t.Errorf("i=%d: expression mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
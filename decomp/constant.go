@@ -0,0 +1,256 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// parseConstExpr converts the provided LLVM IR constant expression into an
+// equivalent Go AST expression node. Arithmetic on integer constants is folded
+// into a basic literal, and constant getelementptr expressions are represented
+// by address-of index expressions.
+//
+// Examples:
+//    i32 add (i32 40, i32 2)                          ; 42
+//    i32* getelementptr ([4 x i32]* @a, i64 0, i64 2) ; &a[2]
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#constant-expressions
+func parseConstExpr(c llvm.Value) (ast.Expr, error) {
+	switch opcode := c.ConstOpcode(); opcode {
+	case llvm.Add:
+		return parseConstBinOp(c, token.ADD)
+	case llvm.Sub:
+		return parseConstBinOp(c, token.SUB)
+	case llvm.Mul:
+		return parseConstBinOp(c, token.MUL)
+	case llvm.SDiv:
+		return parseConstBinOp(c, token.QUO)
+	case llvm.SRem:
+		return parseConstBinOp(c, token.REM)
+	case llvm.Shl:
+		return parseConstBinOp(c, token.SHL)
+	case llvm.And:
+		return parseConstBinOp(c, token.AND)
+	case llvm.Or:
+		return parseConstBinOp(c, token.OR)
+	case llvm.Xor:
+		return parseConstBinOp(c, token.XOR)
+	case llvm.GetElementPtr:
+		return parseConstGEP(c)
+	default:
+		return nil, errutil.Newf("support for LLVM IR constant expression %q not yet implemented", prettyOpcode(opcode))
+	}
+}
+
+// parseConstBinOp converts the provided LLVM IR binary constant expression into
+// an equivalent Go AST expression node. The expression is folded into a basic
+// literal if both operands are integer literals.
+//
+//    i32 add (i32 40, i32 2) ; 42
+func parseConstBinOp(c llvm.Value, op token.Token) (ast.Expr, error) {
+	x, err := parseOperand(c.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	y, err := parseOperand(c.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	if typ := c.Type(); typ.TypeKind() == llvm.IntegerTypeKind {
+		if lit, ok := foldIntLits(x, op, y, typ.IntTypeWidth()); ok {
+			return lit, nil
+		}
+	}
+	return &ast.BinaryExpr{X: x, Op: op, Y: y}, nil
+}
+
+// foldIntLits evaluates the binary operation of the provided integer literals of
+// the given bit width, and returns the result as a basic literal and a boolean
+// indicating success. The result wraps around to the bit width, and is
+// sign-extended like the operands.
+//
+//    i8 add (i8 100, i8 100) ; -56
+func foldIntLits(x ast.Expr, op token.Token, y ast.Expr, width int) (*ast.BasicLit, bool) {
+	if width < 1 || width > 64 {
+		return nil, false
+	}
+	xlit, ok := x.(*ast.BasicLit)
+	if !ok || xlit.Kind != token.INT {
+		return nil, false
+	}
+	ylit, ok := y.(*ast.BasicLit)
+	if !ok || ylit.Kind != token.INT {
+		return nil, false
+	}
+	a, err := strconv.ParseInt(xlit.Value, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	b, err := strconv.ParseInt(ylit.Value, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	var v int64
+	switch op {
+	case token.ADD:
+		v = a + b
+	case token.SUB:
+		v = a - b
+	case token.MUL:
+		v = a * b
+	case token.QUO, token.REM:
+		if b == 0 {
+			return nil, false
+		}
+		if op == token.QUO {
+			v = a / b
+		} else {
+			v = a % b
+		}
+	case token.SHL:
+		if b < 0 || b >= int64(width) {
+			return nil, false
+		}
+		v = a << uint(b)
+	case token.AND:
+		v = a & b
+	case token.OR:
+		v = a | b
+	case token.XOR:
+		v = a ^ b
+	default:
+		return nil, false
+	}
+	if width < 64 {
+		shift := uint(64 - width)
+		v = v << shift >> shift
+	}
+	return &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(v, 10)}, true
+}
+
+// parseConstGEP converts the provided LLVM IR getelementptr constant expression
+// into an equivalent Go AST expression node (an address-of expression).
+//
+//    i32* getelementptr ([4 x i32]* @a, i64 0, i64 2)   ; &a[2]
+//    i32* getelementptr (%struct.foo* @p, i64 0, i32 1) ; &p._1
+//    i32* getelementptr (i32* @x, i64 0)                ; &x
+//
+// Syntax:
+//    getelementptr [inbounds] (<ty>* <ptrval>{, <ty> <idx>}*)
+func parseConstGEP(c llvm.Value) (ast.Expr, error) {
+	if c.OperandsCount() < 2 {
+		return nil, errutil.Newf("invalid getelementptr constant expression; expected >= 2 operands, got %d", c.OperandsCount())
+	}
+
+	// Parse the pointer operand and the first index, which steps through the
+	// pointer operand.
+	//
+	//    getelementptr ([4 x i32]* @a, i64 0, i64 2) ; &a[2]
+	//    getelementptr (i32* @x, i64 0)              ; &x
+	ptr := c.Operand(0)
+	x, err := parseOperand(ptr)
+	if err != nil {
+		return nil, err
+	}
+	if addr, ok := x.(*ast.UnaryExpr); ok && addr.Op == token.AND {
+		// Memory modeled as a Go value is indexed directly.
+		//    &a ; a
+		x = addr.X
+	}
+	index, err := parseOperand(c.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	if !isZero(index) {
+		x = &ast.IndexExpr{X: x, Index: index}
+	}
+
+	// Parse the remaining indices, which step into arrays and structs.
+	typ := ptr.Type().ElementType()
+	for i := 2; i < c.OperandsCount(); i++ {
+		switch typ.TypeKind() {
+		case llvm.ArrayTypeKind, llvm.VectorTypeKind:
+			//    a[2]
+			index, err := parseOperand(c.Operand(i))
+			if err != nil {
+				return nil, err
+			}
+			x = &ast.IndexExpr{X: x, Index: index}
+			typ = typ.ElementType()
+		case llvm.StructTypeKind:
+			//    p._1
+			index := c.Operand(i)
+			if index.IsAConstantInt().IsNil() {
+				return nil, errutil.New("invalid struct index; expected integer constant")
+			}
+			field := int(index.ZExtValue())
+			fields := typ.StructElementTypes()
+			if field >= len(fields) {
+				return nil, errutil.Newf("invalid struct index; expected < %d, got %d", len(fields), field)
+			}
+			x = &ast.SelectorExpr{X: x, Sel: getFieldName(field)}
+			typ = fields[field]
+		default:
+			return nil, errutil.Newf("support for getelementptr index into %v not yet implemented", typ)
+		}
+	}
+	return &ast.UnaryExpr{Op: token.AND, X: x}, nil
+}
+
+// parseConstAggregate converts the provided LLVM IR structure or array constant
+// into an equivalent Go composite literal.
+//
+//    {i32, i8} {i32 1, i8 2}  ; struct{_0 int32; _1 int8}{1, 2}
+//    [2 x i32] [i32 1, i32 2] ; [2]int32{1, 2}
+func parseConstAggregate(c llvm.Value) (ast.Expr, error) {
+	typ, err := getGoType(c.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lit := &ast.CompositeLit{Type: typ}
+	for i := 0; i < c.OperandsCount(); i++ {
+		elem, err := parseOperand(c.Operand(i))
+		if err != nil {
+			return nil, err
+		}
+		lit.Elts = append(lit.Elts, elem)
+	}
+	return lit, nil
+}
+
+// parseConstString converts the provided LLVM IR i8 array constant into an
+// equivalent Go composite literal.
+//
+//    [3 x i8] c"hi\00" ; [3]int8{104, 105, 0}
+func parseConstString(c llvm.Value) (ast.Expr, error) {
+	typ, err := getGoType(c.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lit := &ast.CompositeLit{Type: typ}
+	for _, b := range []byte(c.ConstGetAsString()) {
+		val := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(int(int8(b)))}
+		lit.Elts = append(lit.Elts, val)
+	}
+	return lit, nil
+}
+
+// parseUndef converts the provided LLVM IR undef or poison value into the Go
+// zero value of its type. The statements which use the value are annotated
+// with a comment noting the original value (see addComment).
+//
+//    i32 undef       ; 0
+//    i8* poison      ; nil
+//    {i32, i8} undef ; struct{_0 int32; _1 int8}{}
+func parseUndef(c llvm.Value) (ast.Expr, error) {
+	zero, err := getZeroValue(c.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return zero, nil
+}
@@ -0,0 +1,57 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+)
+
+func TestFoldIntLits(t *testing.T) {
+	golden := []struct {
+		x, y  string
+		op    token.Token
+		width int
+		want  string
+		ok    bool
+	}{
+		{x: "40", op: token.ADD, y: "2", width: 32, want: "42", ok: true},
+		{x: "100", op: token.ADD, y: "100", width: 8, want: "-56", ok: true},
+		{x: "127", op: token.ADD, y: "1", width: 8, want: "-128", ok: true},
+		{x: "-128", op: token.SUB, y: "1", width: 8, want: "127", ok: true},
+		{x: "300", op: token.MUL, y: "300", width: 16, want: "24464", ok: true},
+		{x: "-7", op: token.QUO, y: "2", width: 32, want: "-3", ok: true},
+		{x: "-7", op: token.REM, y: "2", width: 32, want: "-1", ok: true},
+		{x: "1", op: token.SHL, y: "7", width: 8, want: "-128", ok: true},
+		{x: "1", op: token.SHL, y: "63", width: 64, want: "-9223372036854775808", ok: true},
+		{x: "12", op: token.AND, y: "10", width: 32, want: "8", ok: true},
+		{x: "12", op: token.OR, y: "10", width: 32, want: "14", ok: true},
+		{x: "12", op: token.XOR, y: "-1", width: 32, want: "-13", ok: true},
+		{x: "9223372036854775807", op: token.ADD, y: "1", width: 64, want: "-9223372036854775808", ok: true},
+		// Division by zero.
+		{x: "1", op: token.QUO, y: "0", width: 32},
+		// Shift amounts exceeding the bit width.
+		{x: "1", op: token.SHL, y: "8", width: 8},
+		{x: "1", op: token.SHL, y: "-1", width: 32},
+		// Unsupported operators.
+		{x: "1", op: token.SHR, y: "1", width: 32},
+	}
+	for _, g := range golden {
+		x := &ast.BasicLit{Kind: token.INT, Value: g.x}
+		y := &ast.BasicLit{Kind: token.INT, Value: g.y}
+		lit, ok := foldIntLits(x, g.op, y, g.width)
+		if ok != g.ok {
+			t.Errorf("%s %v %s (i%d): ok mismatch; expected %v, got %v", g.x, g.op, g.y, g.width, g.ok, ok)
+			continue
+		}
+		if ok && lit.Value != g.want {
+			t.Errorf("%s %v %s (i%d): result mismatch; expected %s, got %s", g.x, g.op, g.y, g.width, g.want, lit.Value)
+		}
+	}
+
+	// Operands which are not integer literals are not folded.
+	x := ast.NewIdent("x")
+	y := &ast.BasicLit{Kind: token.INT, Value: "1"}
+	if _, ok := foldIntLits(x, token.ADD, y, 32); ok {
+		t.Errorf("x + 1: expected no folding")
+	}
+}
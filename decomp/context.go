@@ -0,0 +1,81 @@
+package decomp
+
+import (
+	"sync"
+
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// A funcContext holds the decompilation state of a function.
+type funcContext struct {
+	// Decompiler options.
+	opts *Options
+	// Go identifiers of local variables.
+	names *namer
+	// Go identifier of the variadic parameter, or "" if not yet assigned.
+	varArgs string
+	// Index of the next variadic argument accessed by a va_arg instruction.
+	nextVAArg int
+	// Comment lines which precede the next statement of the function.
+	pending []string
+}
+
+var (
+	// contexts maps from LLVM IR function to its decompilation state.
+	contexts = make(map[llvm.Value]*funcContext)
+	// contextsMu protects contexts, as modules may be decompiled in parallel.
+	contextsMu sync.Mutex
+)
+
+// newContext creates the decompilation state of the provided LLVM IR function.
+func newContext(fn llvm.Value, opts *Options) {
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+	contexts[fn] = &funcContext{
+		opts:  opts,
+		names: newNamer(),
+	}
+}
+
+// releaseContext releases the decompilation state of the provided LLVM IR
+// function.
+func releaseContext(fn llvm.Value) {
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+	delete(contexts, fn)
+}
+
+// getContext returns the decompilation state of the function containing the
+// provided LLVM IR value, or nil if not present (e.g. for global variables and
+// constants).
+func getContext(v llvm.Value) *funcContext {
+	fn := v
+	if v.IsAFunction().IsNil() {
+		fn = parentFunc(v)
+	}
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+	return contexts[fn]
+}
+
+// getOptions returns the decompiler options of the function containing the
+// provided LLVM IR value, or the default options if not present.
+func getOptions(v llvm.Value) *Options {
+	if ctx := getContext(v); ctx != nil {
+		return ctx.opts
+	}
+	return &Options{}
+}
+
+// parentFunc returns the LLVM IR function containing the provided instruction
+// or parameter, and a nil value otherwise (e.g. for global variables and
+// constants).
+func parentFunc(v llvm.Value) llvm.Value {
+	switch {
+	case !v.IsAInstruction().IsNil():
+		return v.InstructionParent().Parent()
+	case !v.IsAArgument().IsNil():
+		return v.ParamParent()
+	}
+	return llvm.Value{}
+}
@@ -0,0 +1,132 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// removeRedundantConvs removes the conversions of the function body whose
+// operand is already of the target type, as tracked by a map from local
+// variables to their types. The types of the parameters of the function
+// signature, of declared variables and of variables defined by typed
+// expressions are tracked.
+//
+//    // from:
+//    _2 := int32(_x)
+//    _3 := int32(_2 + 1)
+//
+//    // to:
+//    _2 := int32(_x)
+//    _3 := _2 + 1
+func removeRedundantConvs(sig *ast.FuncType, body *ast.BlockStmt) {
+	// Locate the types of the local variables; variables which are assigned
+	// values of distinct types are not tracked.
+	types := make(map[string]string)
+	conflicts := make(map[string]bool)
+	addType := func(name, typ string) {
+		if prev, ok := types[name]; ok && prev != typ {
+			conflicts[name] = true
+		}
+		types[name] = typ
+	}
+	for _, field := range sig.Params.List {
+		if typ, ok := field.Type.(*ast.Ident); ok {
+			for _, name := range field.Names {
+				addType(name.Name, typ.Name)
+			}
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if typ, ok := n.Type.(*ast.Ident); ok {
+				for _, name := range n.Names {
+					addType(name.Name, typ.Name)
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+				break
+			}
+			if ident, ok := n.Lhs[0].(*ast.Ident); ok {
+				if typ := getExprType(n.Rhs[0], types); len(typ) > 0 {
+					addType(ident.Name, typ)
+				}
+			}
+		}
+		return true
+	})
+	for name := range conflicts {
+		delete(types, name)
+	}
+
+	// Remove redundant conversions.
+	rewriteExprs(body, func(expr ast.Expr) ast.Expr {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return expr
+		}
+		typ, ok := call.Fun.(*ast.Ident)
+		if !ok || !convTypes[typ.Name] {
+			return expr
+		}
+		if getExprType(call.Args[0], types) != typ.Name {
+			return expr
+		}
+		return call.Args[0]
+	})
+}
+
+// getExprType returns the name of the predeclared type of the provided
+// expression, based on the given types of local variables, or "" if unknown.
+// Untyped constant expressions have no known type.
+//
+//    int32(x) ; int32
+//    x + 1    ; type of x
+func getExprType(expr ast.Expr, types map[string]string) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return types[expr.Name]
+	case *ast.ParenExpr:
+		return getExprType(expr.X, types)
+	case *ast.CallExpr:
+		if typ, ok := expr.Fun.(*ast.Ident); ok && convTypes[typ.Name] && len(expr.Args) == 1 {
+			return typ.Name
+		}
+	case *ast.UnaryExpr:
+		switch expr.Op {
+		case token.SUB, token.XOR:
+			return getExprType(expr.X, types)
+		}
+	case *ast.BinaryExpr:
+		switch expr.Op {
+		case token.SHL, token.SHR:
+			return getExprType(expr.X, types)
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM, token.AND, token.OR, token.XOR, token.AND_NOT:
+			x, y := getExprType(expr.X, types), getExprType(expr.Y, types)
+			switch {
+			case len(x) > 0 && (x == y || isLit(expr.Y)):
+				return x
+			case len(y) > 0 && isLit(expr.X):
+				return y
+			}
+		}
+	}
+	return ""
+}
+
+// convTypes specifies the predeclared types of the conversions which may be
+// removed.
+var convTypes = map[string]bool{
+	"int8":    true,
+	"int16":   true,
+	"int32":   true,
+	"int64":   true,
+	"uint8":   true,
+	"uint16":  true,
+	"uint32":  true,
+	"uint64":  true,
+	"uintptr": true,
+	"float32": true,
+	"float64": true,
+}
@@ -0,0 +1,92 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestRemoveRedundantConvs(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Conversion of a parameter to its own type.
+		{
+			src:  "func f(x int32) int32 { return int32(x) }",
+			want: "func f(x int32) int32 {\n\treturn x\n}",
+		},
+		// Nested conversions to the same type.
+		{
+			src:  "func f(x int8) int32 { return int32(int32(x)) }",
+			want: "func f(x int8) int32 {\n\treturn int32(x)\n}",
+		},
+		// Variables defined by typed expressions; the operand is parenthesized.
+		{
+			src:  "func f(x int8) int32 {\n_2 := int32(x)\n_3 := int32(_2+1) * 2\nreturn _3\n}",
+			want: "func f(x int8) int32 {\n\t_2 := int32(x)\n\t_3 := (_2 + 1) * 2\n\treturn _3\n}",
+		},
+		// Declared variables.
+		{
+			src:  "func f() uint32 {\nvar x uint32\nreturn uint32(x)\n}",
+			want: "func f() uint32 {\n\tvar x uint32\n\treturn x\n}",
+		},
+		// Untyped constants and conversions to other types are kept.
+		{
+			src:  "func f(x int32) int64 { return int64(x) + int64(1) }",
+			want: "func f(x int32) int64 {\n\treturn int64(x) + int64(1)\n}",
+		},
+		// Variables of distinct types are not tracked.
+		{
+			src:  "func f(x int32) int32 {\nif x < 0 {\nx := int8(x)\n_ = x\n}\nreturn int32(x)\n}",
+			want: "func f(x int32) int32 {\n\tif x < 0 {\n\t\tx := int8(x)\n\t\t_ = x\n\t}\n\treturn int32(x)\n}",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		removeRedundantConvs(f.Type, f.Body)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+// parseTestFunc parses the provided Go function declaration, and removes the
+// positions of its nodes to print it as if generated.
+func parseTestFunc(src string) (*ast.FuncDecl, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+src, 0)
+	if err != nil {
+		return nil, err
+	}
+	f := file.Decls[0].(*ast.FuncDecl)
+	clearPos(reflect.ValueOf(f))
+	return f, nil
+}
+
+// clearPos removes the positions of the node held by v.
+func clearPos(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() && v.Type() != objectType && v.Type() != scopeType {
+			clearPos(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			clearPos(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			clearPos(v.Field(i))
+		}
+	default:
+		if v.Type() == posType {
+			v.SetInt(0)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package decomp
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"log"
+
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+var (
+	// Logger logs non-error messages (e.g. progress and warnings). The messages
+	// are discarded by default.
+	Logger = log.New(ioutil.Discard, "", 0)
+	// Debug logs diagnostic output (e.g. intermediate basic blocks and control
+	// flow primitives). The output is discarded by default.
+	Debug = log.New(ioutil.Discard, "", 0)
+)
+
+// isDebug reports whether diagnostic output is enabled.
+func isDebug() bool {
+	return Debug.Writer() != ioutil.Discard
+}
+
+// debugValue dumps the LLVM IR value to the debug logger.
+func debugValue(v llvm.Value) {
+	if !isDebug() || v.IsNil() {
+		return
+	}
+	s, err := hackDump(v)
+	if err != nil {
+		Debug.Println(err)
+		return
+	}
+	Debug.Print(s)
+}
+
+// printBB pretty-prints the basic block to the debug logger.
+func printBB(bb BasicBlock) {
+	if !isDebug() {
+		return
+	}
+	buf := new(bytes.Buffer)
+	printer.Fprint(buf, token.NewFileSet(), bb.Stmts())
+	Debug.Printf("--- [ basic block %q ] ---\n%s\n", bb.Name(), buf)
+	debugValue(bb.Term())
+}
+
+// printFunc pretty-prints the function to the debug logger.
+func printFunc(f *ast.FuncDecl) {
+	if !isDebug() {
+		return
+	}
+	buf := new(bytes.Buffer)
+	printer.Fprint(buf, token.NewFileSet(), f)
+	Debug.Printf("--- [ function %q ] ---\n%s\n", f.Name, buf)
+}
@@ -0,0 +1,384 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// declareVars hoists a variable declaration to the top of the function body for
+// each identifier which is assigned more than once (e.g. in two merged basic
+// blocks), assigned without being defined (e.g. lowered PHI instructions), or
+// used outside of the block which defines it (e.g. a value of a loop body which
+// is used after the loop). The definitions of hoisted identifiers are converted
+// into assignments. Identifiers which are only used by an if, for or switch
+// statement are instead defined by its init statement (see defineInitVars).
+//
+// Example:
+//    // from:
+//    if cond {
+//       x := 1
+//    } else {
+//       x := 2
+//    }
+//
+//    // to:
+//    var x int32
+//    if cond {
+//       x = 1
+//    } else {
+//       x = 2
+//    }
+func declareVars(llFunc llvm.Value, body *ast.BlockStmt) error {
+	names, assigns := findHoistedVars(body)
+	if len(names) == 0 {
+		return nil
+	}
+
+	// Locate the types of the hoisted identifiers.
+	types, err := getVarTypes(llFunc, names)
+	if err != nil {
+		return errutil.Err(err)
+	}
+
+	// Define the identifiers which are only used by an if, for or switch
+	// statement in its init statement, rather than hoisting them.
+	names = defineInitVars(body, names, types)
+	if len(names) == 0 {
+		return nil
+	}
+
+	// Create the variable declaration and convert definitions into assignments.
+	//    var x int32
+	decl := &ast.GenDecl{
+		Tok: token.VAR,
+	}
+	if len(names) > 1 {
+		// Any valid position is sufficient to trigger a parenthesized variable
+		// declaration.
+		decl.Lparen = 1
+	}
+	for _, name := range names {
+		typ, ok := types[name]
+		if !ok {
+			return errutil.Newf("unable to locate type of variable %q", name)
+		}
+		spec := &ast.ValueSpec{
+			Names: []*ast.Ident{newIdent(name)},
+			Type:  typ,
+		}
+		decl.Specs = append(decl.Specs, spec)
+		for _, stmt := range assigns[name] {
+			stmt.Tok = token.ASSIGN
+		}
+	}
+	body.List = append([]ast.Stmt{&ast.DeclStmt{Decl: decl}}, body.List...)
+	return nil
+}
+
+// findHoistedVars returns the sorted names of the identifiers of the function
+// body which must be declared at the top of the function body, and the
+// assignments of each identifier. Identifiers are hoisted if they are assigned
+// more than once, assigned without being defined, or used outside of the scope
+// of their definition; i.e. the enclosing block, or the if, for or switch
+// statement of an init statement.
+//
+//    for {
+//       x := g()  ; x is hoisted
+//       ...
+//    }
+//    h(x)
+func findHoistedVars(body *ast.BlockStmt) (names []string, assigns map[string][]*ast.AssignStmt) {
+	// Locate the assignments, definitions and definition scopes of each
+	// identifier.
+	assigns = make(map[string][]*ast.AssignStmt)
+	defined := make(map[string]bool)
+	declared := make(map[string]bool)
+	scopes := make(map[string]ast.Node)
+	var stack []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != 1 {
+				break
+			}
+			ident, ok := n.Lhs[0].(*ast.Ident)
+			if !ok || ident.Name == "_" {
+				break
+			}
+			assigns[ident.Name] = append(assigns[ident.Name], n)
+			if n.Tok == token.DEFINE {
+				defined[ident.Name] = true
+				scopes[ident.Name] = defScope(stack, n)
+			}
+		case *ast.ValueSpec:
+			for _, ident := range n.Names {
+				declared[ident.Name] = true
+			}
+		}
+		stack = append(stack, n)
+		return true
+	})
+	for name, stmts := range assigns {
+		if declared[name] {
+			continue
+		}
+		switch {
+		case len(stmts) > 1, !defined[name]:
+			names = append(names, name)
+		case scopes[name] != nil && countIdents(scopes[name], name) != countIdents(body, name):
+			// Used outside of the scope of the definition.
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, assigns
+}
+
+// defScope returns the innermost node of the stack of ancestors which scopes
+// the identifier defined by the provided assignment statement; either a block,
+// a case clause, or an if, for or switch statement of which the assignment is
+// the init statement.
+func defScope(stack []ast.Node, def *ast.AssignStmt) ast.Node {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+			return n
+		case *ast.IfStmt:
+			if n.Init == def {
+				return n
+			}
+		case *ast.ForStmt:
+			if n.Init == def {
+				return n
+			}
+		case *ast.SwitchStmt:
+			if n.Init == def {
+				return n
+			}
+		}
+	}
+	return nil
+}
+
+// defineInitVars converts the assignments of the init statements of if, for and
+// switch statements into definitions, for the provided identifiers which are
+// not used outside of the statement. Untyped constants are converted to the
+// given types of the identifiers. The identifiers which remain to be hoisted are
+// returned.
+//
+//    // from:
+//    for i = 0; i < 10; i++ {
+//
+//    // to:
+//    for i := int32(0); i < 10; i++ {
+func defineInitVars(body *ast.BlockStmt, names []string, types map[string]ast.Expr) []string {
+	hoisted := make(map[string]bool)
+	for _, name := range names {
+		hoisted[name] = true
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		var init ast.Stmt
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			init = n.Init
+		case *ast.ForStmt:
+			init = n.Init
+		case *ast.SwitchStmt:
+			init = n.Init
+		}
+		assign, ok := init.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return true
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok || !hoisted[ident.Name] || countIdents(n, ident.Name) != countIdents(body, ident.Name) {
+			return true
+		}
+		typ, ok := types[ident.Name]
+		if !ok {
+			return true
+		}
+		// The remaining definitions within the statement are converted into
+		// assignments.
+		ast.Inspect(n, func(n ast.Node) bool {
+			if def, ok := n.(*ast.AssignStmt); ok && def.Tok == token.DEFINE && sameIdent(def.Lhs, ident) {
+				def.Tok = token.ASSIGN
+			}
+			return true
+		})
+		assign.Tok = token.DEFINE
+		if isUntypedConst(assign.Rhs[0]) && !(isBoolLit(assign.Rhs[0]) && sameTypeName(typ, ast.NewIdent("bool"))) {
+			assign.Rhs[0] = newConv(typ, assign.Rhs[0])
+		}
+		delete(hoisted, ident.Name)
+		return true
+	})
+	var remaining []string
+	for _, name := range names {
+		if hoisted[name] {
+			remaining = append(remaining, name)
+		}
+	}
+	return remaining
+}
+
+// isUntypedConst returns true if the provided expression is an untyped constant
+// expression (e.g. 1 << 3, or nil), and false otherwise.
+func isUntypedConst(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		return isLit(expr) || isBoolLit(expr)
+	case *ast.ParenExpr:
+		return isUntypedConst(expr.X)
+	case *ast.UnaryExpr:
+		return isUntypedConst(expr.X)
+	case *ast.BinaryExpr:
+		return isUntypedConst(expr.X) && isUntypedConst(expr.Y)
+	}
+	return false
+}
+
+// getVarTypes returns the Go types of the provided identifiers, based on the
+// types of the LLVM IR instructions defining them.
+func getVarTypes(llFunc llvm.Value, names []string) (map[string]ast.Expr, error) {
+	want := make(map[string]bool)
+	for _, name := range names {
+		want[name] = true
+	}
+	types := make(map[string]ast.Expr)
+	for _, llBB := range funcBlocks(llFunc) {
+		for inst := llBB.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+			if inst.Type().TypeKind() == llvm.VoidTypeKind {
+				continue
+			}
+			result, err := getResult(inst)
+			if err != nil {
+				// Skip instructions without a result (e.g. store and br).
+				continue
+			}
+			ident, ok := result.(*ast.Ident)
+			if !ok || !want[ident.Name] {
+				continue
+			}
+			typ, err := getGoType(inst.Type())
+			if err != nil {
+				return nil, errutil.Err(err)
+			}
+			types[ident.Name] = typ
+		}
+	}
+	return types, nil
+}
+
+// mergeVarDecls merges the consecutive variable declarations of the function
+// body into a single declaration, and merges the consecutive variable specs of
+// the same type without initial values. The recorded comment lines of the merged
+// declarations are moved to the merged declaration.
+//
+//    // from:
+//    var x int32
+//    var y int32
+//    var p *int32 = nil
+//
+//    // to:
+//    var (
+//       x, y int32
+//       p    *int32 = nil
+//    )
+func mergeVarDecls(body *ast.BlockStmt, comments commentMap) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			n.List = mergeVarStmts(n.List, comments)
+		case *ast.CaseClause:
+			n.Body = mergeVarStmts(n.Body, comments)
+		}
+		return true
+	})
+}
+
+// mergeVarStmts merges the consecutive variable declarations of the provided
+// statement list.
+func mergeVarStmts(stmts []ast.Stmt, comments commentMap) []ast.Stmt {
+	var list []ast.Stmt
+	var prev *ast.GenDecl
+	for _, stmt := range stmts {
+		decl := getVarDecl(stmt)
+		if decl == nil || prev == nil {
+			prev = decl
+			list = append(list, stmt)
+			continue
+		}
+		prev.Specs = append(prev.Specs, decl.Specs...)
+		if lines, ok := comments[stmt]; ok {
+			delete(comments, stmt)
+			merged := list[len(list)-1]
+			comments[merged] = append(comments[merged], lines...)
+		}
+	}
+	for _, stmt := range list {
+		if decl := getVarDecl(stmt); decl != nil {
+			mergeVarSpecs(decl)
+		}
+	}
+	return list
+}
+
+// mergeVarSpecs merges the consecutive variable specs of the provided variable
+// declaration which are of the same type and have no initial values.
+func mergeVarSpecs(decl *ast.GenDecl) {
+	var specs []ast.Spec
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		if n := len(specs); n > 0 {
+			prev := specs[n-1].(*ast.ValueSpec)
+			if len(prev.Values) == 0 && len(spec.Values) == 0 && sameTypeName(prev.Type, spec.Type) {
+				prev.Names = append(prev.Names, spec.Names...)
+				continue
+			}
+		}
+		specs = append(specs, spec)
+	}
+	decl.Specs = specs
+	decl.Lparen = token.NoPos
+	if len(specs) > 1 {
+		// Any valid position is sufficient to trigger a parenthesized variable
+		// declaration.
+		decl.Lparen = 1
+	}
+}
+
+// getVarDecl returns the variable declaration of the provided statement, or nil
+// if the statement is not a variable declaration.
+func getVarDecl(stmt ast.Stmt) *ast.GenDecl {
+	declStmt, ok := stmt.(*ast.DeclStmt)
+	if !ok {
+		return nil
+	}
+	decl, ok := declStmt.Decl.(*ast.GenDecl)
+	if !ok || decl.Tok != token.VAR {
+		return nil
+	}
+	return decl
+}
+
+// sameTypeName returns true if the provided types are the same named type (e.g.
+// int32), and false otherwise.
+func sameTypeName(x, y ast.Expr) bool {
+	a, ok := x.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	b, ok := y.(*ast.Ident)
+	return ok && a.Name == b.Name
+}
@@ -0,0 +1,176 @@
+package decomp
+
+import (
+	"go/ast"
+	"strings"
+	"testing"
+)
+
+func TestMergeVarDecls(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Matching types.
+		{
+			src:  "func f() {\nvar x int32\nvar y int32\ng(x, y)\n}",
+			want: "func f() {\n\tvar x, y int32\n\tg(x, y)\n}",
+		},
+		// Mixed types, grouped declarations and initial values.
+		{
+			src:  "func f() {\nvar (\nx int32\ny int32\n)\nvar p *int32 = nil\nvar z int32\ng(x, y, p, z)\n}",
+			want: "func f() {\n\tvar (\n\t\tx, y\tint32\n\t\tp\t*int32\t= nil\n\t\tz\tint32\n\t)\n\tg(x, y, p, z)\n}",
+		},
+		// Declarations which are not consecutive.
+		{
+			src:  "func f() {\nvar x int32\ng(x)\nvar y int32\ng(y)\n}",
+			want: "func f() {\n\tvar x int32\n\tg(x)\n\tvar y int32\n\tg(y)\n}",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		mergeVarDecls(f.Body, nil)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+func TestMergeVarDeclsComments(t *testing.T) {
+	f, err := parseTestFunc("func f() {\nvar x int32\nvar y int8\ng(x, y)\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	declX, declY := f.Body.List[0], f.Body.List[1]
+	comments := commentMap{declX: {"from: %x = alloca i32"}, declY: {"from: %y = alloca i8"}}
+	mergeVarDecls(f.Body, comments)
+	want := []string{"from: %x = alloca i32", "from: %y = alloca i8"}
+	if got := comments[declX]; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("comments mismatch; expected %q, got %q", want, got)
+	}
+}
+
+func TestDefineInitVars(t *testing.T) {
+	golden := []struct {
+		src string
+		// Identifier to hoist.
+		name string
+		want string
+		// Identifier which remains to be hoisted, if any.
+		hoisted string
+	}{
+		// Init statement of a for statement; untyped constants are converted.
+		{
+			src:  "func f() {\nfor i = 0; i < 10; i++ {\ng(i)\n}\n}",
+			name: "i",
+			want: "func f() {\n\tfor i := int32(0); i < 10; i++ {\n\t\tg(i)\n\t}\n}",
+		},
+		// Init statement of an if statement, with a definition in its body.
+		{
+			src:  "func f(x int32) {\nif i = x; i < 10 {\ni := 2\ng(i)\n}\n}",
+			name: "i",
+			want: "func f(x int32) {\n\tif i := x; i < 10 {\n\t\ti = 2\n\t\tg(i)\n\t}\n}",
+		},
+		// Init statement of a switch statement; boolean constants are typed.
+		{
+			src:  "func f() {\nswitch c = true; c {\ncase true:\ng(c)\n}\n}",
+			name: "c",
+			want: "func f() {\n\tswitch c := true; c {\n\tcase true:\n\t\tg(c)\n\t}\n}",
+		},
+		// Identifiers used outside of the statement are hoisted.
+		{
+			src:     "func f() {\nfor i = 0; i < 10; i++ {\n}\ng(i)\n}",
+			name:    "i",
+			want:    "func f() {\n\tfor i = 0; i < 10; i++ {\n\t}\n\tg(i)\n}",
+			hoisted: "i",
+		},
+		// Identifiers of pointer type.
+		{
+			src:  "func f() {\nif p = nil; p == nil {\ng(p)\n}\n}",
+			name: "p",
+			want: "func f() {\n\tif p := (*int32)(nil); p == nil {\n\t\tg(p)\n\t}\n}",
+		},
+	}
+	types := map[string]ast.Expr{
+		"i": ast.NewIdent("int32"),
+		"c": ast.NewIdent("bool"),
+		"p": &ast.StarExpr{X: ast.NewIdent("int32")},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		hoisted := defineInitVars(f.Body, []string{g.name}, types)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+		if got := strings.Join(hoisted, ","); got != g.hoisted {
+			t.Errorf("i=%d: hoisted identifiers mismatch; expected %q, got %q", i, g.hoisted, got)
+		}
+	}
+}
+
+func TestFindHoistedVars(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Definitions used within their scope.
+		{
+			src:  "func f() {\nx := 1\nif x < 2 {\ny := x\ng(y)\n}\n}",
+			want: "",
+		},
+		// Assigned more than once.
+		{
+			src:  "func f() {\nif c {\nx := 1\ng(x)\n} else {\nx := 2\ng(x)\n}\n}",
+			want: "x",
+		},
+		// Assigned without being defined.
+		{
+			src:  "func f() {\nx = 1\ng(x)\n}",
+			want: "x",
+		},
+		// Defined in a loop body and used after the loop.
+		{
+			src:  "func f() {\nfor {\nx := g()\nif x {\nbreak\n}\n}\ng(x)\n}",
+			want: "x",
+		},
+		// Defined in a case clause and used after the switch statement.
+		{
+			src:  "func f() {\nswitch c {\ncase 1:\nx := 1\ng(x)\n}\ng(x)\n}",
+			want: "x",
+		},
+		// Defined by an init statement and used within the statement.
+		{
+			src:  "func f() {\nif x := g(); x {\n} else {\ng(x)\n}\n}",
+			want: "",
+		},
+		// Defined by an init statement and used after the statement.
+		{
+			src:  "func f() {\nif x := g(); x {\n}\ng(x)\n}",
+			want: "x",
+		},
+		// Declared identifiers.
+		{
+			src:  "func f() {\nvar x int32\nx = 1\ng(x)\n}",
+			want: "",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		names, _ := findHoistedVars(f.Body)
+		if got := strings.Join(names, ","); got != g.want {
+			t.Errorf("i=%d: hoisted identifiers mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
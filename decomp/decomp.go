@@ -0,0 +1,814 @@
+// Package decomp implements a decompiler from LLVM IR to Go source code.
+//
+// The control flow of each function is recovered using the external restructure
+// tool, which locates control flow primitives (e.g. loops and 2-way
+// conditionals) in the control flow graph of the function. Tools which already
+// hold an LLVM IR module may instead decompile it in-process using Decompile and
+// the Structure option, without touching the file system.
+package decomp
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	xprimitive "decomp.org/x/graphs/primitive"
+	"github.com/mewfork/dot"
+	"github.com/mewkiz/pkg/errutil"
+	"github.com/mewkiz/pkg/osutil"
+	"github.com/mewkiz/pkg/pathutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// Options specifies the decompiler options.
+type Options struct {
+	// Funcs specifies the functions to decompile; all function definitions are
+	// decompiled if empty.
+	Funcs []string
+	// ExcludeFuncs specifies function definitions to exclude from decompilation
+	// when Funcs is empty.
+	ExcludeFuncs []string
+	// PkgName specifies the package name if non-empty; otherwise "main" is used
+	// by Decompile, and DecompileFile uses the base name of the input file
+	// unless a main function is decompiled. Characters which are invalid in Go
+	// identifiers are dropped from the package name (e.g. "123-foo" =>
+	// "_123foo").
+	PkgName string
+	// GraphsDir specifies the directory of the control flow graphs and the
+	// structuring results of each function (e.g. "foo_graphs"), which are
+	// reused between runs. A temporary directory is used if empty.
+	GraphsDir string
+	// TmpDir specifies the directory of temporary files if non-empty; otherwise
+	// the default directory for temporary files is used.
+	TmpDir string
+	// When Dot is true, store the control flow graph of each function as a DOT
+	// file in GraphsDir (e.g. foo_graphs/bar.dot).
+	Dot bool
+	// When KeepGraphs is true, DecompileFile keeps the graphs directory after
+	// decompilation; otherwise it is removed if created by DecompileFile.
+	KeepGraphs bool
+	// When Goto is true, emit goto statements for unstructured control flow.
+	Goto bool
+	// When Unsafe is true, use unsafe.Pointer conversions for pointer casts.
+	Unsafe bool
+	// When Comments is true, annotate the generated statements with the LLVM IR
+	// instructions they originate from. Comments require Fset.
+	Comments bool
+	// When Fences is true, mark fence instructions with a comment (e.g.
+	// "// fence seq_cst"); otherwise they are dropped, as Go has no equivalent
+	// of explicit memory barriers. Comments require Fset.
+	Fences bool
+	// Fset specifies the file set of the positions of comments, which must be
+	// passed to the printer of the Go source file. Comments are only produced
+	// by Decompile, and are omitted if Fset is nil.
+	Fset *token.FileSet
+	// When KeepGoing is true, continue decompiling the remaining functions after
+	// a function fails to decompile. Failed functions are emitted as stubs which
+	// panic, and the failures are returned as Errors alongside the Go source
+	// file.
+	KeepGoing bool
+	// Ptr specifies the pointer model of alloca instructions; either PtrValue or
+	// PtrPointer (default).
+	Ptr string
+	// LocalPrefix specifies the prefix of the Go identifiers of local variable
+	// IDs (e.g. "v" for "%42" => "v42"); "_" is used if empty.
+	LocalPrefix string
+	// When SkipEH is true, functions which make use of exception handling
+	// (invoke, landingpad and resume instructions) are emitted as stubs which
+	// panic, rather than failing to decompile.
+	SkipEH bool
+	// Timeout specifies the maximum duration of the decompilation of each
+	// function if non-zero. Functions which exceed the timeout fail to
+	// decompile (and are emitted as stubs when KeepGoing is true).
+	Timeout time.Duration
+	// When IgnoreUnwind is true, assume that no exceptions are thrown; invoke
+	// instructions are translated into calls followed by a branch to their
+	// normal destination, and basic blocks only reachable through unwind
+	// destinations are dropped.
+	IgnoreUnwind bool
+	// MainWrap specifies the name of a function which is called by a generated
+	// main function if non-empty, with zero-value arguments; its result is
+	// printed. The package name defaults to "main" when MainWrap is set.
+	MainWrap string
+	// Structure specifies a function which locates the control flow primitives
+	// of the control flow graph of the named function in-process. The graphs
+	// directory and the external restructure tool are only used if Structure is
+	// nil.
+	Structure func(ctx context.Context, funcName string, graph *dot.Graph) ([]*xprimitive.Primitive, error)
+	// When ZeroInit is true, explicitly initialize the Go variables of alloca
+	// instructions to their zero value (e.g. "var _p *int32 = nil"), when using
+	// the PtrValue pointer model.
+	ZeroInit bool
+
+	// comments records the comment lines of the generated statements, if Fset
+	// is non-nil.
+	comments commentMap
+}
+
+// DecompileFile parses the provided LLVM IR assembly file and decompiles it to
+// a Go source file.
+func DecompileFile(llPath string, opts Options) (*ast.File, error) {
+	ctx := llvm.NewContext()
+	defer ctx.Dispose()
+	return DecompileFileContext(ctx, llPath, opts)
+}
+
+// DecompileFileContext parses the provided LLVM IR assembly file into a module
+// of the given LLVM context and decompiles it to a Go source file. The module is
+// disposed after decompilation, while the context is owned by the caller and
+// may be reused to decompile several files (e.g. one context per worker).
+//
+// Named structure types are uniqued per context, so a structure type of a
+// later module may be renamed (e.g. "%struct.foo.0"); the numeric suffix is
+// dropped from the Go identifier.
+func DecompileFileContext(ctx llvm.Context, llPath string, opts Options) (file *ast.File, err error) {
+	// Parse foo.ll
+	module, err := parseModule(ctx, llPath, opts.TmpDir)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	defer module.Dispose()
+
+	// Locate package name.
+	if len(opts.PkgName) == 0 && len(opts.MainWrap) == 0 {
+		opts.PkgName = pathutil.FileName(llPath)
+		for _, funcName := range getFuncNames(module, opts) {
+			if funcName == "main" {
+				opts.PkgName = "main"
+				break
+			}
+		}
+	}
+
+	// Reuse control flow graphs and structuring results, e.g.
+	//
+	//    foo.ll -> foo_graphs/*.json
+	//
+	// The graphs directory is removed after decompilation if created by this
+	// run, unless requested to be kept by the KeepGraphs or Dot options.
+	if len(opts.GraphsDir) == 0 && (opts.Structure == nil || opts.Dot) {
+		graphsDir := pathutil.TrimExt(llPath) + "_graphs"
+		opts.GraphsDir = graphsDir
+		if exists, _ := osutil.Exists(graphsDir); !exists && !opts.KeepGraphs && !opts.Dot {
+			defer func() {
+				if e := os.RemoveAll(graphsDir); e != nil && err == nil {
+					file, err = nil, errutil.Err(e)
+				}
+			}()
+		}
+	}
+
+	return Decompile(module, opts)
+}
+
+// Decompile decompiles the provided LLVM IR module to a Go source file. The
+// module is owned by the caller, and may have been created in-process (e.g. by
+// the pass pipeline of a tool) rather than parsed from a file. The file system
+// is not accessed if the Structure option is set and the Dot option is not.
+func Decompile(module llvm.Module, opts Options) (*ast.File, error) {
+	cleanup, err := initOptions(&opts)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	defer cleanup()
+	defer releaseGlobalNames(module)
+	if opts.Fset != nil {
+		opts.comments = make(commentMap)
+	}
+	pkgName := "main"
+	if len(opts.PkgName) > 0 {
+		pkgName, err = sanitizePkgName(opts.PkgName)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+	}
+
+	// Create foo.go.
+	file := &ast.File{
+		Name: ast.NewIdent(pkgName),
+	}
+
+	// Declare the named structure types referenced by the global variables and
+	// the functions to decompile.
+	types, err := parseTypes(module, getFuncNames(module, opts))
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	file.Decls = append(file.Decls, types...)
+
+	// Parse global variables.
+	globals, err := parseGlobals(module)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	file.Decls = append(file.Decls, globals...)
+
+	// Parse each function.
+	err = DecompileEach(module, opts, func(f *ast.FuncDecl) error {
+		file.Decls = append(file.Decls, f)
+		return nil
+	})
+	errs, ok := err.(Errors)
+	if err != nil && !ok {
+		return nil, err
+	}
+
+	// Create a main function which calls the function to wrap.
+	if len(opts.MainWrap) > 0 {
+		f, err := createMainWrapper(module, opts.MainWrap)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		file.Decls = append(file.Decls, f)
+	}
+
+	// Position the comments of the statements, and add import declarations.
+	positionComments(opts.Fset, file, opts.comments)
+	addImports(file)
+
+	if len(errs) > 0 {
+		return file, errs
+	}
+	return file, nil
+}
+
+// DecompileEach decompiles the functions of the provided LLVM IR module, and
+// invokes fn with the Go function declaration of each function as soon as it
+// has been decompiled. Contrary to Decompile, the function declarations are not
+// accumulated, which allows the caller to write them incrementally. Type and
+// global variable declarations and import declarations are not produced.
+//
+// Decompilation stops at the first error returned by fn.
+func DecompileEach(module llvm.Module, opts Options, fn func(f *ast.FuncDecl) error) error {
+	cleanup, err := initOptions(&opts)
+	if err != nil {
+		return errutil.Err(err)
+	}
+	defer cleanup()
+	defer releaseGlobalNames(module)
+
+	var errs Errors
+	for _, funcName := range getFuncNames(module, opts) {
+		Logger.Printf("Parsing function: %q\n", funcName)
+		f, err := decompileFunc(module, funcName, &opts)
+		if err != nil {
+			if !opts.KeepGoing {
+				return err
+			}
+			// Emit a stub for the failed function and continue with the
+			// remaining functions.
+			errs = append(errs, err)
+			f, err = createStubFunc(module, funcName, err)
+			if err != nil {
+				return errutil.Err(err)
+			}
+		}
+		printFunc(f)
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// initOptions validates the provided decompiler options and fills in their
+// defaults. The returned cleanup function removes the temporary graphs
+// directory, if any.
+func initOptions(opts *Options) (cleanup func(), err error) {
+	if len(opts.Ptr) == 0 {
+		opts.Ptr = PtrPointer
+	}
+	if opts.Ptr != PtrValue && opts.Ptr != PtrPointer {
+		return nil, errutil.Newf("invalid pointer model %q; expected %q or %q", opts.Ptr, PtrValue, PtrPointer)
+	}
+	if len(opts.LocalPrefix) == 0 {
+		opts.LocalPrefix = "_"
+	}
+	if !isIdentPrefix(opts.LocalPrefix) {
+		return nil, errutil.Newf("invalid local variable prefix %q; expected a letter or underscore, followed by letters, digits and underscores", opts.LocalPrefix)
+	}
+	if len(opts.GraphsDir) == 0 && (opts.Structure == nil || opts.Dot) {
+		graphsDir, err := ioutil.TempDir(opts.TmpDir, "ll2go")
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		opts.GraphsDir = graphsDir
+		return func() { os.RemoveAll(graphsDir) }, nil
+	}
+	return func() {}, nil
+}
+
+// decompileFunc decompiles the provided function of the LLVM IR module to a Go
+// function declaration.
+func decompileFunc(module llvm.Module, funcName string, opts *Options) (*ast.FuncDecl, error) {
+	llFunc := module.NamedFunction(funcName)
+	if llFunc.IsNil() {
+		return nil, errutil.Newf("unable to locate function %q", funcName)
+	}
+	newContext(llFunc, opts)
+	defer releaseContext(llFunc)
+
+	// Exception handling is not supported; report it before the control flow
+	// analysis, which would otherwise fail on the unwind edges.
+	if inst := findEHInst(llFunc); !inst.IsNil() {
+		bbName, err := getBBName(inst.InstructionParent().AsValue())
+		if err != nil {
+			return nil, funcError(funcName, err)
+		}
+		err = funcError(funcName, blockError(bbName, inst, newEHError(inst)))
+		if opts.SkipEH {
+			Logger.Printf("Skipping function %q: %v\n", funcName, err)
+			return createStubFunc(module, funcName, err)
+		}
+		return nil, err
+	}
+
+	if opts.Timeout <= 0 {
+		return structureFunc(context.Background(), llFunc, module, funcName, opts)
+	}
+
+	// Decompile the function in a separate goroutine, which is abandoned if it
+	// exceeds the timeout. The abandoned goroutine stops at its next
+	// cancellation point (e.g. between the passes of parseFunc).
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	type result struct {
+		f   *ast.FuncDecl
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		f, err := structureFunc(ctx, llFunc, module, funcName, opts)
+		done <- result{f: f, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.f, res.err
+	case <-ctx.Done():
+		return nil, funcError(funcName, errutil.Newf("decompilation of function %q timed out after %v", funcName, opts.Timeout))
+	}
+}
+
+// structureFunc creates the control flow graph of the provided function,
+// locates its control flow primitives and decompiles it to a Go function
+// declaration. Decompilation stops when the provided context is done.
+func structureFunc(ctx context.Context, llFunc llvm.Value, module llvm.Module, funcName string, opts *Options) (*ast.FuncDecl, error) {
+	graph, err := createCFG(llFunc)
+	if err != nil {
+		return nil, funcError(funcName, err)
+	}
+	hprims, err := getPrims(ctx, graph, funcName, opts)
+	if err != nil {
+		return nil, funcError(funcName, err)
+	}
+	return parseFunc(ctx, graph, module, funcName, hprims, opts)
+}
+
+// createStubFunc creates a Go function declaration for the provided function
+// of the LLVM IR module, which failed to decompile. The body of the function
+// panics with the decompilation error.
+//
+//    func foo(x int32) int32 {
+//       panic("decompile failed: ...")
+//    }
+func createStubFunc(module llvm.Module, funcName string, failure error) (*ast.FuncDecl, error) {
+	sig := &ast.FuncType{
+		Params: &ast.FieldList{},
+	}
+	if llFunc := module.NamedFunction(funcName); !llFunc.IsNil() && funcName != "main" {
+		if s, err := parseSig(llFunc); err == nil {
+			sig = s
+		}
+	}
+	msg := &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote("decompile failed: " + failure.Error())}
+	call := &ast.CallExpr{Fun: newIdent("panic"), Args: []ast.Expr{msg}}
+	body := &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: call}}}
+	return createFunc(globalName(module, funcName), sig, body)
+}
+
+// createMainWrapper creates a main function which calls the provided function
+// of the LLVM IR module with the zero value of each parameter type, and prints
+// its result.
+//
+// Example:
+//    func main() {
+//       fmt.Println(add(0, 0))
+//    }
+func createMainWrapper(module llvm.Module, funcName string) (*ast.FuncDecl, error) {
+	if llFunc := module.NamedFunction("main"); !llFunc.IsNil() && !llFunc.IsDeclaration() {
+		return nil, errutil.Newf("unable to wrap function %q; main function already defined", funcName)
+	}
+	llFunc := module.NamedFunction(funcName)
+	if llFunc.IsNil() || llFunc.IsDeclaration() {
+		return nil, errutil.Newf("unable to locate function definition %q to wrap", funcName)
+	}
+	var args []ast.Expr
+	for _, param := range llFunc.Params() {
+		arg, err := getZeroValue(param.Type())
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		args = append(args, arg)
+	}
+	var stmt ast.Stmt = &ast.ExprStmt{X: &ast.CallExpr{Fun: ast.NewIdent(globalName(module, funcName)), Args: args}}
+	if llFunc.Type().ElementType().ReturnType().TypeKind() != llvm.VoidTypeKind {
+		//    fmt.Println(add(0, 0))
+		fun := newPkgSelector("fmt", "Println")
+		call := stmt.(*ast.ExprStmt).X
+		stmt = &ast.ExprStmt{X: &ast.CallExpr{Fun: fun, Args: []ast.Expr{call}}}
+	}
+	sig := &ast.FuncType{Params: &ast.FieldList{}}
+	body := &ast.BlockStmt{List: []ast.Stmt{stmt}}
+	return createFunc("main", sig, body)
+}
+
+// getFuncNames returns the names of the functions to decompile.
+func getFuncNames(module llvm.Module, opts Options) []string {
+	if len(opts.Funcs) > 0 {
+		return opts.Funcs
+	}
+	exclude := make(map[string]bool)
+	for _, funcName := range opts.ExcludeFuncs {
+		exclude[funcName] = true
+	}
+	var funcNames []string
+	for llFunc := module.FirstFunction(); !llFunc.IsNil(); llFunc = llvm.NextFunction(llFunc) {
+		if llFunc.IsDeclaration() {
+			// Ignore function declarations (e.g. functions without bodies).
+			continue
+		}
+		if exclude[llFunc.Name()] {
+			continue
+		}
+		funcNames = append(funcNames, llFunc.Name())
+	}
+	return funcNames
+}
+
+// getPrims returns the control flow primitives of the provided control flow
+// graph, as located by the restructure tool. The structuring results are reused
+// if present in the graphs directory. The restructure tool is killed if the
+// provided context is done before it completes.
+//
+// The Structure option, if set, locates the primitives in-process instead.
+func getPrims(ctx context.Context, graph *dot.Graph, funcName string, opts *Options) ([]*xprimitive.Primitive, error) {
+	if opts.Structure != nil {
+		return structure(ctx, graph, funcName, opts)
+	}
+
+	// Store the CFG, e.g.
+	//
+	//    foo.ll -> foo_graphs/*.dot
+	//
+	// The CFG is only stored when requested by the Dot option or when required
+	// as input to the restructure tool.
+	dotName := funcName + ".dot"
+	dotPath := path.Join(opts.GraphsDir, dotName)
+	jsonName := funcName + ".json"
+	jsonPath := path.Join(opts.GraphsDir, jsonName)
+	hasJSON, _ := osutil.Exists(jsonPath)
+	if opts.Dot || !hasJSON {
+		err := storeCFG(dotPath, graph)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+	}
+
+	// Structure the CFG.
+	if !hasJSON {
+		cmd := exec.CommandContext(ctx, "restructure", "-o", jsonPath, dotPath)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		Logger.Printf("Structuring function: %q\n", funcName)
+		err := cmd.Run()
+		if ctx.Err() == context.DeadlineExceeded {
+			// Remove partial structuring results, which would otherwise be
+			// reused by later runs.
+			os.Remove(jsonPath)
+			return nil, errutil.Newf("structuring of function %q timed out after %v", funcName, opts.Timeout)
+		}
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		if !opts.Dot {
+			err = os.Remove(dotPath)
+			if err != nil {
+				return nil, errutil.Err(err)
+			}
+		}
+	}
+	var hprims []*xprimitive.Primitive
+	fr, err := os.Open(jsonPath)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	defer fr.Close()
+	dec := json.NewDecoder(fr)
+	err = dec.Decode(&hprims)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return hprims, nil
+}
+
+// structure returns the control flow primitives of the provided control flow
+// graph, as located in-process by the Structure option. The CFG is only stored
+// in the graphs directory when requested by the Dot option.
+func structure(ctx context.Context, graph *dot.Graph, funcName string, opts *Options) ([]*xprimitive.Primitive, error) {
+	if opts.Dot {
+		dotPath := path.Join(opts.GraphsDir, funcName+".dot")
+		err := storeCFG(dotPath, graph)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+	}
+	Logger.Printf("Structuring function: %q\n", funcName)
+	hprims, err := opts.Structure(ctx, funcName, graph)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, errutil.Newf("structuring of function %q timed out after %v", funcName, opts.Timeout)
+	}
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return hprims, nil
+}
+
+// parseFunc parses the given function and attempts to construct an equivalent
+// Go function declaration AST node. Parsing stops when the provided context is
+// done.
+func parseFunc(ctx context.Context, graph *dot.Graph, module llvm.Module, funcName string, hprims []*xprimitive.Primitive, opts *Options) (f *ast.FuncDecl, err error) {
+	// Record the function name in errors.
+	defer func() {
+		if err != nil {
+			err = funcError(funcName, err)
+		}
+	}()
+
+	llFunc := module.NamedFunction(funcName)
+	if llFunc.IsNil() {
+		return nil, errutil.Newf("unable to locate function %q", funcName)
+	}
+	if llFunc.IsDeclaration() {
+		return nil, errutil.Newf("unable to create AST for %q; expected function definition, got function declaration (e.g. no body)", funcName)
+	}
+
+	// Parse each basic block.
+	bbs := make(map[string]BasicBlock)
+	for _, llBB := range funcBlocks(llFunc) {
+		if err := ctx.Err(); err != nil {
+			return nil, errutil.Err(err)
+		}
+		bb, err := parseBasicBlock(llBB)
+		if err != nil {
+			return nil, err
+		}
+		bbs[bb.Name()] = bb
+		printBB(bb)
+	}
+
+	// Replace PHI instructions with assignment statements in the appropriate
+	// basic blocks.
+	err = lowerPHIs(llFunc, bbs)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Perform control flow analysis.
+	entry, err := getBBName(llFunc.EntryBasicBlock().AsValue())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	body, err := restructure(graph, bbs, hprims, entry, opts)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, errutil.Err(err)
+	}
+	inlineTemps(body, opts.LocalPrefix, opts.comments)
+	normalizeCmps(body)
+	simplifyBoolCmps(body)
+	reduceSwitchChains(body)
+	simplifyLoopConds(body)
+	foldLoopClauses(body)
+	removeUnusedAssigns(body)
+	if err := ctx.Err(); err != nil {
+		return nil, errutil.Err(err)
+	}
+	err = declareVars(llFunc, body)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	mergeVarDecls(body, opts.comments)
+	simplifyIncDecs(body, opts.comments)
+	sig := &ast.FuncType{
+		Params: &ast.FieldList{},
+	}
+	if funcName != "main" {
+		sig, err = parseSig(llFunc)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+	}
+	removeRedundantConvs(sig, body)
+	return createFunc(globalName(module, funcName), sig, body)
+}
+
+// parseSig parses the function signature of the provided LLVM IR function and
+// attempts to construct an equivalent Go function type AST node. Parameters are
+// named after their LLVM IR value names, and unnamed parameters are named after
+// their ID (e.g. "_0", "_1").
+//
+// Syntax:
+//    define i32 @add(i32 %x, i32 %y)
+func parseSig(llFunc llvm.Value) (*ast.FuncType, error) {
+	sig := &ast.FuncType{
+		Params: &ast.FieldList{},
+	}
+
+	// Parse parameters.
+	id := 0
+	for _, param := range llFunc.Params() {
+		typ, err := getGoType(param.Type())
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		name := param.Name()
+		if len(name) == 0 {
+			// Unnamed parameters are given consecutive IDs (e.g. "%0").
+			name = strconv.Itoa(id)
+			id++
+		}
+		field := &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(localName(llFunc, name))},
+			Type:  typ,
+		}
+		sig.Params.List = append(sig.Params.List, field)
+	}
+
+	// Parse variadic parameter; the types of the variadic arguments are unknown
+	// and are asserted by each va_arg instruction.
+	//    args ...interface{}
+	if llFunc.Type().ElementType().IsFunctionVarArg() {
+		field := &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(varArgsName(llFunc))},
+			Type:  &ast.Ellipsis{Elt: &ast.InterfaceType{Methods: &ast.FieldList{}}},
+		}
+		sig.Params.List = append(sig.Params.List, field)
+	}
+
+	// Parse return type. Aggregate values are returned as a single Go struct or
+	// array.
+	//    {i32, i32} ; struct{_0 int32; _1 int32}
+	retType := llFunc.Type().ElementType().ReturnType()
+	if retType.TypeKind() != llvm.VoidTypeKind {
+		typ, err := getGoType(retType)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		sig.Results = &ast.FieldList{
+			List: []*ast.Field{{Type: typ}},
+		}
+	}
+
+	return sig, nil
+}
+
+// createFunc creates and returns a Go function declaration based on the
+// provided function name, function signature and basic block.
+func createFunc(name string, sig *ast.FuncType, body *ast.BlockStmt) (*ast.FuncDecl, error) {
+	f := &ast.FuncDecl{
+		Name: newIdent(name),
+		Type: sig,
+		Body: body,
+	}
+	return f, nil
+}
+
+// newPkgSelector returns a selector expression of the named member of the
+// package with the given import path (e.g. unsafe.Pointer). The package is
+// recorded by the object of the package identifier, so that addImports does not
+// mistake identifiers of the generated code (e.g. a local variable named fmt)
+// for package references.
+func newPkgSelector(pkgPath, name string) *ast.SelectorExpr {
+	pkgName := path.Base(pkgPath)
+	x := ast.NewIdent(pkgName)
+	x.Obj = ast.NewObj(ast.Pkg, pkgName)
+	x.Obj.Data = pkgPath
+	return &ast.SelectorExpr{X: x, Sel: ast.NewIdent(name)}
+}
+
+// addImports adds an import declaration of the packages referenced by the
+// generated Go source code to the file, as recorded by newPkgSelector. The
+// package paths are sorted to produce a deterministic output.
+func addImports(file *ast.File) {
+	// Locate package references (e.g. unsafe.Pointer).
+	imports := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj != nil && x.Obj.Kind == ast.Pkg {
+				if pkgPath, ok := x.Obj.Data.(string); ok {
+					imports[pkgPath] = true
+				}
+			}
+		}
+		return true
+	})
+	if len(imports) == 0 {
+		return
+	}
+	var pkgPaths []string
+	for pkgPath := range imports {
+		pkgPaths = append(pkgPaths, pkgPath)
+	}
+	sort.Strings(pkgPaths)
+	decl := &ast.GenDecl{
+		Tok: token.IMPORT,
+	}
+	if len(pkgPaths) > 1 {
+		// Any valid position is sufficient to trigger a parenthesized import
+		// declaration.
+		decl.Lparen = 1
+	}
+	for _, pkgPath := range pkgPaths {
+		spec := &ast.ImportSpec{
+			Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(pkgPath)},
+		}
+		decl.Specs = append(decl.Specs, spec)
+		file.Imports = append(file.Imports, spec)
+	}
+	file.Decls = append([]ast.Decl{decl}, file.Decls...)
+}
+
+// parseModule parses the provided LLVM IR assembly file into a module of the
+// given context. The assembly is parsed in-memory using the IR parser of the
+// LLVM bindings, and as a fallback assembled into a temporary bitcode file
+// using llvm-as, e.g.
+//
+//    foo.ll -> $TMPDIR/ll2go123/foo.bc
+func parseModule(ctx llvm.Context, llPath, tmpDir string) (llvm.Module, error) {
+	// Parse foo.ll in-memory. Note, the IR parser takes ownership of the memory
+	// buffer.
+	buf, err := llvm.NewMemoryBufferFromFile(llPath)
+	if err != nil {
+		return llvm.Module{}, errutil.Err(err)
+	}
+	module, err := ctx.ParseIR(buf)
+	if err == nil {
+		return module, nil
+	}
+	Logger.Printf("Unable to parse %q in-memory; falling back to llvm-as: %v\n", filepath.Base(llPath), err)
+
+	// Create temporary foo.bc file in a unique temporary directory.
+	bcDir, err := ioutil.TempDir(tmpDir, "ll2go")
+	if err != nil {
+		return llvm.Module{}, errutil.Err(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(bcDir); err != nil {
+			Logger.Println(errutil.Err(err))
+		}
+	}()
+	bcPath := filepath.Join(bcDir, pathutil.FileName(llPath)+".bc")
+	cmd := exec.Command("llvm-as", "-o", bcPath, llPath)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return llvm.Module{}, errutil.Err(err)
+	}
+
+	// Parse foo.bc
+	module, err = ctx.ParseBitcodeFile(bcPath)
+	if err != nil {
+		return llvm.Module{}, errutil.Err(err)
+	}
+	return module, nil
+}
+
+// storeCFG stores the control flow graph to the given path, creating parent
+// directories as needed.
+func storeCFG(dotPath string, graph *dot.Graph) error {
+	err := os.MkdirAll(path.Dir(dotPath), 0755)
+	if err != nil {
+		return errutil.Err(err)
+	}
+	err = ioutil.WriteFile(dotPath, []byte(graph.String()), 0644)
+	if err != nil {
+		return errutil.Err(err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package decomp
+
+import (
+	"context"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	xprimitive "decomp.org/x/graphs/primitive"
+	"github.com/mewfork/dot"
+)
+
+func TestAddImports(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// No package references.
+		{
+			src:  "func f(x int32) int32 {\nreturn x\n}",
+			want: "package p\n\nfunc f(x int32) int32 {\n\treturn x\n}\n",
+		},
+		// Single package reference.
+		{
+			src:  "func f(p *int32) unsafe.Pointer {\nreturn unsafe.Pointer(p)\n}",
+			want: "package p\n\nimport \"unsafe\"\n\nfunc f(p *int32) unsafe.Pointer {\n\treturn unsafe.Pointer(p)\n}\n",
+		},
+		// Sorted package paths, referenced multiple times.
+		{
+			src:  "func f(p *int32) {\nfmt.Println(atomic.LoadInt32(p))\nfmt.Println(unsafe.Pointer(p))\n}",
+			want: "package p\n\nimport (\n\t\"fmt\"\n\t\"sync/atomic\"\n\t\"unsafe\"\n)\n\nfunc f(p *int32) {\n\tfmt.Println(atomic.LoadInt32(p))\n\tfmt.Println(unsafe.Pointer(p))\n}\n",
+		},
+		// Unknown packages.
+		{
+			src:  "func f() {\nos.Exit(1)\n}",
+			want: "package p\n\nfunc f() {\n\tos.Exit(1)\n}\n",
+		},
+		// Identifiers which are not package references (e.g. a local variable
+		// named fmt).
+		{
+			src:  "func f(fmt *T) int32 {\nreturn fmt._0\n}",
+			want: "package p\n\nfunc f(fmt *T) int32 {\n\treturn fmt._0\n}\n",
+		},
+	}
+	// Package references of the test sources, as created by newPkgSelector.
+	pkgs := map[string]string{"atomic": "sync/atomic", "fmt": "fmt", "unsafe": "unsafe"}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil {
+					if pkgPath, ok := pkgs[x.Name]; ok {
+						sel.X = newPkgSelector(pkgPath, sel.Sel.Name).X
+					}
+				}
+			}
+			return true
+		})
+		file := &ast.File{Name: ast.NewIdent("p"), Decls: []ast.Decl{f}}
+		addImports(file)
+		if got := sprintNode(file); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+		if want := strings.Count(g.want, "\"") / 2; len(file.Imports) != want {
+			t.Errorf("i=%d: number of imports mismatch; expected %d, got %d", i, want, len(file.Imports))
+		}
+	}
+}
+
+func TestDecompileTimeout(t *testing.T) {
+	const src = `
+define i32 @f(i32 %x) {
+entry:
+  ret i32 %x
+}
+
+define i32 @g(i32 %x) {
+entry:
+  ret i32 %x
+}
+`
+	// The structuring of f does not complete within the timeout, and ignores
+	// the cancellation of its context.
+	structure := func(ctx context.Context, funcName string, graph *dot.Graph) ([]*xprimitive.Primitive, error) {
+		if funcName == "f" {
+			time.Sleep(500 * time.Millisecond)
+		}
+		return nil, nil
+	}
+	opts := Options{Structure: structure, Timeout: 50 * time.Millisecond, KeepGoing: true}
+	start := time.Now()
+	got, err := decompileTest(src, opts, nil)
+	if d := time.Since(start); d >= 500*time.Millisecond {
+		t.Errorf("decompilation not abandoned after timeout; took %v", d)
+	}
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("expected one decompilation error, got %v", err)
+	}
+	const timedOut = `decompilation of function "f" timed out after 50ms`
+	if !strings.Contains(errs[0].Error(), timedOut) {
+		t.Errorf("error mismatch; expected %q, got %q", timedOut, errs[0])
+	}
+	if !strings.Contains(got, "func g(x int32) int32 {\n\treturn x\n}") {
+		t.Errorf("function g not decompiled; got %q", got)
+	}
+}
+
+func TestDecompileDeterministic(t *testing.T) {
+	// The output must not depend on the iteration order of maps, e.g. of the
+	// PHI instructions, type definitions and imports.
+	const src = `
+%T = type { i32, i64 }
+%U = type { i8, %T }
+%V = type { %U*, i32 }
+
+@t = global %T zeroinitializer
+@u = global %U zeroinitializer
+@v = global %V zeroinitializer
+
+define i32 @f(i32 %x) {
+entry:
+  %c = icmp slt i32 %x, 0
+  br i1 %c, label %then, label %else
+
+then:
+  br label %exit
+
+else:
+  br label %exit
+
+exit:
+  %a = phi i32 [ 1, %then ], [ 2, %else ]
+  %b = phi i32 [ 3, %then ], [ 4, %else ]
+  %d = phi i32 [ 5, %then ], [ 6, %else ]
+  %e = phi i32 [ 7, %then ], [ 8, %else ]
+  %s1 = add i32 %a, %b
+  %s2 = add i32 %d, %e
+  %s3 = add i32 %s1, %s2
+  ret i32 %s3
+}
+`
+	structure := func(funcName string) []*xprimitive.Primitive {
+		return []*xprimitive.Primitive{
+			{Prim: "if_else", Nodes: map[string]string{"A": "entry", "B": "then", "C": "else", "D": "exit"}, Node: "if0"},
+		}
+	}
+	want, err := decompileTest(src, Options{}, structure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		got, err := decompileTest(src, Options{}, structure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("i=%d: output mismatch; expected %q, got %q", i, want, got)
+		}
+	}
+}
+
+// decompileTest decompiles the provided LLVM IR assembly to Go source code. The
+// control flow primitives of every function are located by structure, which
+// takes the place of the restructure tool.
+func decompileTest(src string, opts Options, structure func(funcName string) []*xprimitive.Primitive) (string, error) {
+	dir, err := ioutil.TempDir("", "ll2go")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	llPath := filepath.Join(dir, "test.ll")
+	if err := ioutil.WriteFile(llPath, []byte(src), 0644); err != nil {
+		return "", err
+	}
+	if opts.Structure == nil {
+		opts.Structure = func(ctx context.Context, funcName string, graph *dot.Graph) ([]*xprimitive.Primitive, error) {
+			return structure(funcName), nil
+		}
+	}
+	if len(opts.PkgName) == 0 {
+		opts.PkgName = "p"
+	}
+	file, err := DecompileFile(llPath, opts)
+	if file == nil {
+		return "", err
+	}
+	return sprintNode(file), err
+}
@@ -0,0 +1,101 @@
+package decomp
+
+import (
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// findEHInst returns the first exception handling instruction (invoke,
+// landingpad or resume) of the provided LLVM IR function, or a nil value if the
+// function doesn't make use of exception handling. Invoke instructions are
+// supported when the IgnoreUnwind option is set.
+//
+// References:
+//    http://llvm.org/docs/ExceptionHandling.html
+func findEHInst(llFunc llvm.Value) llvm.Value {
+	for _, llBB := range funcBlocks(llFunc) {
+		for inst := llBB.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+			if isEHInst(inst) && !ignoresUnwind(inst) {
+				return inst
+			}
+		}
+	}
+	return llvm.Value{}
+}
+
+// isEHInst returns true if the provided LLVM IR instruction is an exception
+// handling instruction, and false otherwise.
+func isEHInst(inst llvm.Value) bool {
+	switch inst.InstructionOpcode() {
+	case llvm.Invoke, llvm.LandingPad, llvm.Resume:
+		return true
+	}
+	return false
+}
+
+// newEHError returns an error which reports that the provided exception
+// handling instruction is not supported.
+func newEHError(inst llvm.Value) error {
+	return errutil.Newf("exception handling not supported (%q instruction)", prettyOpcode(inst.InstructionOpcode()))
+}
+
+// ignoresUnwind returns true if the provided terminator instruction is an invoke
+// instruction whose unwind destination is ignored (see Options.IgnoreUnwind),
+// and false otherwise. Such invoke instructions are translated into a call
+// followed by an unconditional branch to the normal destination.
+//
+// Syntax:
+//    <result> = invoke <ty> <fnptrval>(<function args>) to label <normal label> unwind label <exception label>
+func ignoresUnwind(term llvm.Value) bool {
+	return !term.IsNil() && term.InstructionOpcode() == llvm.Invoke && getOptions(term).IgnoreUnwind
+}
+
+// getSuccessors returns the successors of the provided terminator instruction.
+// The unwind destination of invoke instructions is omitted if ignored.
+func getSuccessors(term llvm.Value) []llvm.BasicBlock {
+	n := term.SuccessorsCount()
+	if ignoresUnwind(term) {
+		// The normal destination is the first successor of invoke instructions,
+		// followed by the unwind destination.
+		n = 1
+	}
+	var succs []llvm.BasicBlock
+	for i := 0; i < n; i++ {
+		succs = append(succs, term.Successor(i))
+	}
+	return succs
+}
+
+// funcBlocks returns the basic blocks of the provided LLVM IR function to
+// decompile. Basic blocks which are only reachable through ignored unwind
+// destinations (e.g. landing pads) are omitted.
+func funcBlocks(llFunc llvm.Value) []llvm.BasicBlock {
+	llBBs := llFunc.BasicBlocks()
+	if !getOptions(llFunc).IgnoreUnwind {
+		return llBBs
+	}
+	entry := llFunc.EntryBasicBlock()
+	reachable := map[llvm.BasicBlock]bool{entry: true}
+	queue := []llvm.BasicBlock{entry}
+	for len(queue) > 0 {
+		llBB := queue[0]
+		queue = queue[1:]
+		term := llBB.LastInstruction()
+		if term.IsNil() {
+			continue
+		}
+		for _, succ := range getSuccessors(term) {
+			if !reachable[succ] {
+				reachable[succ] = true
+				queue = append(queue, succ)
+			}
+		}
+	}
+	var blocks []llvm.BasicBlock
+	for _, llBB := range llBBs {
+		if reachable[llBB] {
+			blocks = append(blocks, llBB)
+		}
+	}
+	return blocks
+}
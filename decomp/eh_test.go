@@ -0,0 +1,73 @@
+package decomp
+
+import (
+	"strings"
+	"testing"
+
+	xprimitive "decomp.org/x/graphs/primitive"
+)
+
+// ehSrc is an LLVM IR function which calls a function that may throw an
+// exception.
+const ehSrc = `
+define i32 @f(i32 %x) personality i8* bitcast (i32 (...)* @__gxx_personality_v0 to i8*) {
+entry:
+  %y = invoke i32 @g(i32 %x)
+          to label %cont unwind label %lpad
+
+cont:
+  ret i32 %y
+
+lpad:
+  %e = landingpad { i8*, i32 }
+          cleanup
+  resume { i8*, i32 } %e
+}
+
+declare i32 @g(i32)
+
+declare i32 @__gxx_personality_v0(...)
+`
+
+func TestDecompileEH(t *testing.T) {
+	structure := func(funcName string) []*xprimitive.Primitive {
+		return nil
+	}
+	golden := []struct {
+		opts Options
+		want string
+		err  string
+	}{
+		// Exception handling is reported clearly.
+		{
+			opts: Options{},
+			err:  `exception handling not supported ("Invoke" instruction)`,
+		},
+		// -skip-eh
+		{
+			opts: Options{SkipEH: true},
+			want: "func f(x int32) int32 {\n\tpanic(",
+		},
+		// -ignore-unwind
+		{
+			opts: Options{IgnoreUnwind: true},
+			want: "func f(x int32) int32 {\n\ty := g(x)\n\treturn y\n}",
+		},
+	}
+	for i, g := range golden {
+		got, err := decompileTest(ehSrc, g.opts, structure)
+		if len(g.err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), g.err) {
+				t.Errorf("i=%d: error mismatch; expected %q, got %v", i, g.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("i=%d: unexpected error; %v", i, err)
+			continue
+		}
+		if !strings.Contains(got, g.want) {
+			t.Errorf("i=%d: output mismatch; expected %q in %q", i, g.want, got)
+		}
+	}
+}
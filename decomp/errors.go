@@ -0,0 +1,76 @@
+package decomp
+
+import (
+	"fmt"
+	"strings"
+
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// An Error is a decompilation error, which records the function, basic block
+// and instruction in which it occurred.
+type Error struct {
+	// Function name.
+	FuncName string
+	// Basic block name; empty if unknown.
+	BlockName string
+	// LLVM IR instruction; empty if unknown.
+	Inst string
+	// Underlying error.
+	Err error
+}
+
+// Error returns a string representation of the error, prefixed by its context.
+//
+// Example:
+//    function "foo", block "3", instruction "%4 = fneg double %x": ...
+func (e *Error) Error() string {
+	var ctx []string
+	if len(e.FuncName) > 0 {
+		ctx = append(ctx, fmt.Sprintf("function %q", e.FuncName))
+	}
+	if len(e.BlockName) > 0 {
+		ctx = append(ctx, fmt.Sprintf("block %q", e.BlockName))
+	}
+	if len(e.Inst) > 0 {
+		ctx = append(ctx, fmt.Sprintf("instruction %q", e.Inst))
+	}
+	if len(ctx) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", strings.Join(ctx, ", "), e.Err)
+}
+
+// blockError returns an error which records the basic block and the LLVM IR
+// instruction (if non-nil) in which err occurred.
+func blockError(blockName string, inst llvm.Value, err error) error {
+	e := &Error{BlockName: blockName, Err: err}
+	if !inst.IsNil() {
+		if s, err := hackDump(inst); err == nil {
+			e.Inst = strings.TrimSpace(s)
+		}
+	}
+	return e
+}
+
+// funcError returns an error which records the function in which err occurred.
+func funcError(funcName string, err error) error {
+	if e, ok := err.(*Error); ok {
+		e.FuncName = funcName
+		return e
+	}
+	return &Error{FuncName: funcName, Err: err}
+}
+
+// Errors records the failures of functions which failed to decompile, when the
+// KeepGoing option is set.
+type Errors []error
+
+// Error returns a summary of the failures, one per line.
+func (errs Errors) Error() string {
+	var lines []string
+	for _, err := range errs {
+		lines = append(lines, err.Error())
+	}
+	return fmt.Sprintf("%d function(s) failed to decompile:\n%s", len(errs), strings.Join(lines, "\n"))
+}
@@ -0,0 +1,60 @@
+package decomp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorError(t *testing.T) {
+	err := errors.New("unsupported")
+	golden := []struct {
+		e    *Error
+		want string
+	}{
+		// No context.
+		{
+			e:    &Error{Err: err},
+			want: "unsupported",
+		},
+		// Function.
+		{
+			e:    &Error{FuncName: "foo", Err: err},
+			want: `function "foo": unsupported`,
+		},
+		// Basic block.
+		{
+			e:    &Error{BlockName: "3", Err: err},
+			want: `block "3": unsupported`,
+		},
+		// Instruction.
+		{
+			e:    &Error{Inst: "%4 = fneg double %x", Err: err},
+			want: `instruction "%4 = fneg double %x": unsupported`,
+		},
+		// Function and basic block.
+		{
+			e:    &Error{FuncName: "foo", BlockName: "3", Err: err},
+			want: `function "foo", block "3": unsupported`,
+		},
+		// Function and instruction.
+		{
+			e:    &Error{FuncName: "foo", Inst: "%4 = fneg double %x", Err: err},
+			want: `function "foo", instruction "%4 = fneg double %x": unsupported`,
+		},
+		// Basic block and instruction.
+		{
+			e:    &Error{BlockName: "3", Inst: "%4 = fneg double %x", Err: err},
+			want: `block "3", instruction "%4 = fneg double %x": unsupported`,
+		},
+		// Function, basic block and instruction.
+		{
+			e:    &Error{FuncName: "foo", BlockName: "3", Inst: "%4 = fneg double %x", Err: err},
+			want: `function "foo", block "3", instruction "%4 = fneg double %x": unsupported`,
+		},
+	}
+	for i, g := range golden {
+		if got := g.e.Error(); got != g.want {
+			t.Errorf("i=%d: error mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
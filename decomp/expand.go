@@ -0,0 +1,214 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// expand attempts to locate and return the definition of the provided
+// identifier expression. The assignment statement defining the identifier is
+// removed from the statement list of the basic block.
+func expand(bb BasicBlock, ident ast.Expr) (ast.Expr, error) {
+	id, ok := ident.(*ast.Ident)
+	if !ok {
+		return nil, errutil.Newf("unable to expand expression; expected identifier, got %T", ident)
+	}
+	var stmts []ast.Stmt
+	var expr ast.Expr
+	for _, stmt := range bb.Stmts() {
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			if sameIdent(stmt.Lhs, id) {
+				if len(stmt.Rhs) != 1 {
+					return nil, errutil.Newf("invalid right-hand side; expected length 1, got %d", len(stmt.Rhs))
+				}
+				expr = stmt.Rhs[0]
+				// TODO: Verify if the identifier is used by any other statement or
+				// terminator instruction before removing it; this includes the use
+				// within other basic blocks.
+
+				// Remove the variable definition of the right-hand side expression
+				// by not appending the assignment statement to the statement list.
+				continue
+			}
+		}
+		stmts = append(stmts, stmt)
+	}
+	if expr == nil {
+		return nil, errutil.Newf("unable to expand definition of identifier %q", id.Name)
+	}
+	bb.SetStmts(stmts)
+	return expr, nil
+}
+
+// expandCond attempts to expand the provided condition of the basic block's
+// terminator. The original condition is returned if its definition could not be
+// located within the basic block, if the definition is used by any other
+// instruction than the terminator, or if any identifier used by the definition
+// is redefined between the definition and the terminator.
+//
+//    // from:
+//    _2 := i < 10
+//    if _2 {
+//
+//    // to:
+//    if i < 10 {
+func expandCond(bb BasicBlock, cond ast.Expr) ast.Expr {
+	id, ok := cond.(*ast.Ident)
+	if !ok {
+		return cond
+	}
+	// The definition is removed by expand, which is only valid if the
+	// terminator is its sole use; e.g. not if the comparison is also used by
+	// a later basic block or a phi instruction.
+	if term := bb.Term(); !term.IsNil() && term.OperandsCount() > 0 {
+		if v := term.Operand(0); !v.IsAInstruction().IsNil() && !hasOneUse(v) {
+			return cond
+		}
+	}
+	stmts := bb.Stmts()
+	for i, stmt := range stmts {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || !sameIdent(assign.Lhs, id) || len(assign.Rhs) != 1 {
+			continue
+		}
+		if redefined(assign.Rhs[0], stmts[i+1:]) {
+			return cond
+		}
+		expr, err := expand(bb, cond)
+		if err != nil {
+			return cond
+		}
+		return expr
+	}
+	return cond
+}
+
+// redefined returns true if any identifier used by the expression may be
+// modified by the provided statements, including nested statements, and false
+// otherwise. Identifiers are modified by assignments to them or to their
+// elements or fields, and by increment and decrement statements; taking the
+// address of an identifier is treated as a modification, as it may be written
+// through the pointer.
+//
+//    x = 1     ; x redefined
+//    x[i] = 1  ; x redefined
+//    x++       ; x redefined
+//    p := &x   ; x redefined
+func redefined(expr ast.Expr, stmts []ast.Stmt) bool {
+	used := make(map[string]bool)
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			used[ident.Name] = true
+		}
+		return true
+	})
+	found := false
+	isUsed := func(x ast.Expr) bool {
+		ident, ok := rootIdent(x)
+		return ok && used[ident.Name]
+	}
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if found {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for _, lhs := range n.Lhs {
+					if isUsed(lhs) {
+						found = true
+					}
+				}
+			case *ast.RangeStmt:
+				if n.Tok == token.ASSIGN && (isUsed(n.Key) || isUsed(n.Value)) {
+					found = true
+				}
+			case *ast.IncDecStmt:
+				if isUsed(n.X) {
+					found = true
+				}
+			case *ast.UnaryExpr:
+				if n.Op == token.AND && isUsed(n.X) {
+					found = true
+				}
+			}
+			return !found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// rootIdent returns the identifier of the variable accessed by the provided
+// expression, through any number of index, selector, dereference and
+// parenthesized expressions, and a boolean indicating success.
+//
+//    x       ; x
+//    x[i]._0 ; x
+//    *p      ; p
+func rootIdent(x ast.Expr) (*ast.Ident, bool) {
+	for {
+		switch expr := x.(type) {
+		case *ast.Ident:
+			return expr, true
+		case *ast.IndexExpr:
+			x = expr.X
+		case *ast.SelectorExpr:
+			x = expr.X
+		case *ast.StarExpr:
+			x = expr.X
+		case *ast.ParenExpr:
+			x = expr.X
+		default:
+			return nil, false
+		}
+	}
+}
+
+// sameIdent returns true if the left-hand side expression list contains only
+// the given expression, and false otherwise.
+func sameIdent(lhs []ast.Expr, ident *ast.Ident) bool {
+	if len(lhs) != 1 {
+		return false
+	}
+	lident, ok := lhs[0].(*ast.Ident)
+	if !ok {
+		return false
+	}
+	return lident.Name == ident.Name
+}
+
+// isReturnedCmp returns true if the operand of the provided ret instruction is a
+// comparison of the same basic block which is used only by the ret instruction,
+// and false otherwise.
+//
+//    %c = icmp sgt i32 %x, 0
+//    ret i1 %c
+func isReturnedCmp(ret llvm.Value) bool {
+	if ret.OperandsCount() != 1 {
+		return false
+	}
+	v := ret.Operand(0)
+	if v.IsAInstruction().IsNil() || v.InstructionParent() != ret.InstructionParent() {
+		return false
+	}
+	switch v.InstructionOpcode() {
+	case llvm.ICmp, llvm.FCmp:
+	default:
+		return false
+	}
+	return hasOneUse(v)
+}
+
+// hasOneUse returns true if the provided LLVM IR value is used exactly once, and
+// false otherwise.
+func hasOneUse(v llvm.Value) bool {
+	use := v.FirstUse()
+	return !use.IsNil() && use.NextUse().IsNil()
+}
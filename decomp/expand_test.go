@@ -0,0 +1,126 @@
+package decomp
+
+import (
+	"go/parser"
+	"testing"
+
+	xprimitive "decomp.org/x/graphs/primitive"
+)
+
+func TestRedefined(t *testing.T) {
+	golden := []struct {
+		src  string
+		want bool
+	}{
+		{src: "y = 1", want: false},
+		{src: "x = 1", want: true},
+		{src: "x, y = 1, 2", want: true},
+		{src: "x[0] = 1", want: true},
+		{src: "x._0 = 1", want: true},
+		{src: "*x = 1", want: true},
+		{src: "x++", want: true},
+		{src: "y--", want: false},
+		{src: "p := &x", want: true},
+		{src: "f(&x)", want: true},
+		{src: "p := &y", want: false},
+		{src: "if y {\nx = 1\n}", want: true},
+		{src: "for {\nx--\n}", want: true},
+		{src: "for x = range y {\n}", want: true},
+		{src: "for y := range z {\n}", want: false},
+		{src: "func() {\nx = 1\n}()", want: true},
+		{src: "y = x + 1", want: false},
+	}
+	expr, err := parser.ParseExpr("x < 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range golden {
+		f, err := parseTestFunc("func f() {\n" + g.src + "\n}")
+		if err != nil {
+			t.Errorf("%q: %v", g.src, err)
+			continue
+		}
+		if got := redefined(expr, f.Body.List); got != g.want {
+			t.Errorf("%q: expected %v, got %v", g.src, g.want, got)
+		}
+	}
+}
+
+func TestRootIdent(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		{src: "x", want: "x"},
+		{src: "x[i]._0", want: "x"},
+		{src: "(*p)[1]", want: "p"},
+		{src: "f()[0]", want: ""},
+	}
+	for _, g := range golden {
+		expr, err := parser.ParseExpr(g.src)
+		if err != nil {
+			t.Errorf("%q: %v", g.src, err)
+			continue
+		}
+		got := ""
+		if ident, ok := rootIdent(expr); ok {
+			got = ident.Name
+		}
+		if got != g.want {
+			t.Errorf("%q: identifier mismatch; expected %q, got %q", g.src, g.want, got)
+		}
+	}
+}
+
+func TestExpandCondMultipleUses(t *testing.T) {
+	// The comparison is used by both the branch and the exit basic block, and
+	// its definition must therefore be kept.
+	const src = `
+define i32 @f(i32 %x) {
+entry:
+  %c = icmp slt i32 %x, 10
+  br i1 %c, label %then, label %exit
+
+then:
+  call void @g()
+  br label %exit
+
+exit:
+  %r = zext i1 %c to i32
+  ret i32 %r
+}
+
+declare void @g()
+`
+	prims := []*xprimitive.Primitive{
+		{Prim: "if", Nodes: map[string]string{"A": "entry", "B": "then", "C": "exit"}, Node: "if0"},
+	}
+	got, err := decompileTest(src, Options{}, func(funcName string) []*xprimitive.Primitive {
+		if funcName == "f" {
+			return prims
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+func f(x int32) int32 {
+	c := x < 10
+	if c {
+		g()
+	}
+	r := func() int32 {
+		if c {
+			return 1
+		}
+		return 0
+	}()
+	return r
+}
+`
+	if got != want {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,95 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// parseGlobals converts the global variable definitions of the provided LLVM
+// IR module into equivalent Go variable declarations. The memory of each global
+// variable is modeled as a Go variable, whose address is taken on use.
+//
+//    @x = global i32 42                                            ; var x int32 = 42
+//    @p = global i32* getelementptr ([4 x i32]* @a, i64 0, i64 2) ; var p *int32 = &a[2]
+//
+// String constants which are only used through getelementptr with zero indices
+// are skipped, as they are translated into Go string literals on use.
+func parseGlobals(module llvm.Module) ([]ast.Decl, error) {
+	var decls []ast.Decl
+	for g := module.FirstGlobal(); !g.IsNil(); g = llvm.NextGlobal(g) {
+		if g.IsDeclaration() {
+			continue
+		}
+		if _, ok := getStringLit(g); ok {
+			continue
+		}
+		name := g.Name()
+		if len(name) == 0 {
+			Logger.Println("Skipping unnamed global variable.")
+			continue
+		}
+		typ, err := getGoType(g.Type().ElementType())
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		spec := &ast.ValueSpec{
+			Names: []*ast.Ident{ast.NewIdent(globalName(module, name))},
+			Type:  typ,
+		}
+
+		// Zero initializers are implicit in Go. Initializers which cannot be
+		// translated yet are left out, rather than failing the decompilation of
+		// the entire module.
+		if init := g.Initializer(); !init.IsNil() && !init.IsNull() {
+			val, err := parseOperand(init)
+			if err != nil {
+				Logger.Printf("Unable to translate initializer of global variable %q: %v\n", name, err)
+			} else {
+				spec.Values = []ast.Expr{val}
+			}
+		}
+		decl := &ast.GenDecl{
+			Tok:   token.VAR,
+			Specs: []ast.Spec{spec},
+		}
+		decls = append(decls, decl)
+	}
+	return decls, nil
+}
+
+// isGlobalVar returns true if the provided LLVM IR value is a named global
+// variable, and false otherwise.
+func isGlobalVar(v llvm.Value) bool {
+	return !v.IsAGlobalVariable().IsNil() && len(v.Name()) > 0
+}
+
+// getAliasee returns the global value aliased by the provided global alias,
+// following chains of aliases and bitcasts. Global aliases have no Go
+// equivalent, so each use is replaced by its aliasee.
+//
+//    @a = alias i32* @x                                      ; x
+//    @f = alias void ()* bitcast (void (i8*)* @g to void ()*) ; g
+func getAliasee(v llvm.Value) llvm.Value {
+	for {
+		switch {
+		case !v.IsAGlobalAlias().IsNil():
+			v = v.Operand(0)
+		case !v.IsAConstantExpr().IsNil() && v.ConstOpcode() == llvm.BitCast:
+			v = v.Operand(0)
+		default:
+			return v
+		}
+	}
+}
+
+// isIFunc returns true if the provided LLVM IR global value is an indirect
+// function (ifunc), i.e. neither a function, a global variable nor a global
+// alias, and false otherwise.
+//
+//    @f = ifunc void (), void ()* ()* @resolve_f
+func isIFunc(v llvm.Value) bool {
+	return !v.IsAGlobalValue().IsNil() && v.IsAFunction().IsNil() && v.IsAGlobalVariable().IsNil() && v.IsAGlobalAlias().IsNil()
+}
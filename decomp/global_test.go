@@ -0,0 +1,117 @@
+package decomp
+
+import (
+	"testing"
+
+	xprimitive "decomp.org/x/graphs/primitive"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+func TestParseGlobalsString(t *testing.T) {
+	// @.str is indexed at a non-zero offset and must be declared, while @.msg
+	// is only used through getelementptr with zero indices.
+	const src = `
+@.str = private unnamed_addr constant [6 x i8] c"hello\00"
+@.msg = private unnamed_addr constant [3 x i8] c"hi\00"
+
+define i8 @f() {
+entry:
+  %p = getelementptr [6 x i8], [6 x i8]* @.str, i64 0, i64 2
+  %c = load i8, i8* %p
+  %r = call i32 @puts(i8* getelementptr ([3 x i8], [3 x i8]* @.msg, i64 0, i64 0))
+  ret i8 %c
+}
+
+declare i32 @puts(i8*)
+`
+	got, err := decompileTest(src, Options{}, func(funcName string) []*xprimitive.Primitive {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+var _str [6]int8 = [6]int8{104, 101, 108, 108, 111, 0}
+
+func f() int8 {
+	p := &_str[2]
+	c := *p
+	_ = puts("hi")
+	return c
+}
+`
+	if got != want {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+}
+
+func TestGetAliasee(t *testing.T) {
+	const src = `
+@x = global i32 1
+@a = alias i32, i32* @x
+@b = alias i32, i32* @a
+@h = alias void (), bitcast (void (i8*)* @g to void ()*)
+@r = ifunc void (), void ()* ()* @resolve
+
+define void @g(i8* %p) {
+entry:
+  ret void
+}
+
+define void ()* @resolve() {
+entry:
+  ret void ()* bitcast (void (i8*)* @g to void ()*)
+}
+
+define void @f() {
+entry:
+  %v = load i32, i32* @b
+  call void @h()
+  call void @r()
+  store i32 %v, i32* @x
+  ret void
+}
+`
+	ctx := llvm.NewContext()
+	defer ctx.Dispose()
+	module, err := parseTestModule(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer module.Dispose()
+	var insts []llvm.Value
+	for inst := module.NamedFunction("f").EntryBasicBlock().FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+		insts = append(insts, inst)
+	}
+	golden := []struct {
+		// Instruction and operand index of the global value.
+		inst, op int
+		// Name of the aliasee.
+		want string
+		// Indirect function.
+		ifunc bool
+	}{
+		// Chain of aliases.
+		//    load i32, i32* @b
+		{inst: 0, op: 0, want: "x"},
+		// Alias of a bitcast function.
+		//    call void @h()
+		{inst: 1, op: 0, want: "g"},
+		// Indirect function.
+		//    call void @r()
+		{inst: 2, op: 0, want: "r", ifunc: true},
+		// Global variable.
+		//    store i32 %v, i32* @x
+		{inst: 3, op: 1, want: "x"},
+	}
+	for _, g := range golden {
+		v := insts[g.inst].Operand(g.op)
+		if got := getAliasee(v).Name(); got != g.want {
+			t.Errorf("inst=%d: aliasee mismatch; expected %q, got %q", g.inst, g.want, got)
+		}
+		if got := isIFunc(v); got != g.ifunc {
+			t.Errorf("inst=%d: ifunc mismatch; expected %v, got %v", g.inst, g.ifunc, got)
+		}
+	}
+}
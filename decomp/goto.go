@@ -0,0 +1,169 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// createGotoBlock creates a block statement of the nodes which remain after
+// structuring the control flow graph of a function. Each node is emitted as a
+// labeled statement (if targeted by any branch), and the terminator of each
+// node is converted into goto statements. The entry node is emitted first,
+// followed by the remaining nodes sorted by name.
+//
+//    // from:
+//    1:
+//       br i1 %2, label %3, label %4
+//
+//    // to:
+//    bb_1:
+//       if _2 {
+//          goto bb_3
+//       }
+//       goto bb_4
+func createGotoBlock(bbs map[string]BasicBlock, aliases map[string]string, entry string) (*ast.BlockStmt, error) {
+	// resolve returns the name of the node which contains the given basic block.
+	resolve := func(name string) string {
+		return resolveAlias(aliases, name)
+	}
+
+	// Sort node names, with the entry node first.
+	entry = resolve(entry)
+	if _, ok := bbs[entry]; !ok {
+		return nil, errutil.Newf("unable to locate entry basic block %q", entry)
+	}
+	var names []string
+	for name := range bbs {
+		if name != entry {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{entry}, names...)
+
+	// Convert terminators into goto statements.
+	stmtsByName := make(map[string][]ast.Stmt)
+	targeted := make(map[string]bool)
+	for _, name := range names {
+		bb := bbs[name]
+		stmts := bb.Stmts()
+		if term := bb.Term(); !term.IsNil() {
+			gotos, targets, err := parseGotoTerm(term, resolve)
+			if err != nil {
+				return nil, errutil.Err(err)
+			}
+			for _, target := range targets {
+				if _, ok := bbs[target]; !ok {
+					return nil, errutil.Newf("unable to locate target basic block %q", target)
+				}
+				targeted[target] = true
+			}
+			stmts = append(stmts, gotos...)
+		}
+		stmtsByName[name] = stmts
+	}
+
+	// Create block statement.
+	block := &ast.BlockStmt{}
+	for _, name := range names {
+		stmts := stmtsByName[name]
+		if targeted[name] && len(stmts) > 0 {
+			labeled := &ast.LabeledStmt{Label: getLabel(name), Stmt: stmts[0]}
+			stmts = append([]ast.Stmt{labeled}, stmts[1:]...)
+		}
+		block.List = append(block.List, stmts...)
+	}
+	return block, nil
+}
+
+// parseGotoTerm converts the provided terminator instruction into equivalent
+// goto statements, and returns the names of its target nodes. The resolve
+// function maps the name of a target basic block to the name of the node which
+// contains it.
+//
+// Syntax:
+//    br label <dest>
+//    br i1 <cond>, label <iftrue>, label <iffalse>
+//    invoke <ty> @foo(<ty> <arg>, ...) to label <normal> unwind label <exception>
+//    switch <intty> <value>, label <defaultdest> [ <intty> <val>, label <dest> ... ]
+func parseGotoTerm(term llvm.Value, resolve func(string) string) (stmts []ast.Stmt, targets []string, err error) {
+	// newGoto returns a goto statement to the node of the given basic block.
+	newGoto := func(target string) *ast.BranchStmt {
+		target = resolve(target)
+		targets = append(targets, target)
+		return &ast.BranchStmt{Tok: token.GOTO, Label: getLabel(target)}
+	}
+
+	switch opcode := term.InstructionOpcode(); opcode {
+	case llvm.Br, llvm.Invoke:
+		if isUncondBr(term) {
+			//    goto bb_1
+			target, err := getBBName(term.Successor(0).AsValue())
+			if err != nil {
+				return nil, nil, errutil.Err(err)
+			}
+			return []ast.Stmt{newGoto(target)}, targets, nil
+		}
+
+		//    if cond {
+		//       goto bb_1
+		//    }
+		//    goto bb_2
+		cond, targetTrue, targetFalse, err := getBrCond(term)
+		if err != nil {
+			return nil, nil, errutil.Err(err)
+		}
+		ifStmt := &ast.IfStmt{
+			Cond: cond,
+			Body: &ast.BlockStmt{List: []ast.Stmt{newGoto(targetTrue)}},
+		}
+		return []ast.Stmt{ifStmt, newGoto(targetFalse)}, targets, nil
+	case llvm.Switch:
+		//    switch x {
+		//    case 1:
+		//       goto bb_1
+		//    default:
+		//       goto bb_2
+		//    }
+		cond, targetDefault, cases, err := getSwitchCases(term)
+		if err != nil {
+			return nil, nil, errutil.Err(err)
+		}
+		var clauses []ast.Stmt
+		for _, c := range cases {
+			clause := &ast.CaseClause{
+				List: []ast.Expr{c.val},
+				Body: []ast.Stmt{newGoto(c.target)},
+			}
+			clauses = append(clauses, clause)
+		}
+		clauses = append(clauses, &ast.CaseClause{Body: []ast.Stmt{newGoto(targetDefault)}})
+		switchStmt := &ast.SwitchStmt{
+			Tag:  cond,
+			Body: &ast.BlockStmt{List: clauses},
+		}
+		return []ast.Stmt{switchStmt}, targets, nil
+	default:
+		return nil, nil, errutil.Newf("support for goto conversion of terminator instruction %q not yet implemented", prettyOpcode(opcode))
+	}
+}
+
+// getLabel returns the Go label of the given node name, after replacing any
+// illegal characters with underscore (e.g. "for.body" => "bb_for_body").
+func getLabel(name string) *ast.Ident {
+	f := func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r):
+			// valid rune in identifier.
+			return r
+		}
+		return '_'
+	}
+	return ast.NewIdent("bb_" + strings.Map(f, name))
+}
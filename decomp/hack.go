@@ -39,38 +39,54 @@
 // LLVM IR has a notion of unnamed variables and basic blocks which are given
 // function scoped IDs during assembly generation. The in-memory representation
 // does not include this ID, so instead of reimplementing the logic of ID slots
-// we capture the output of Value.Dump to locate the basic block names. Note
+// we print the values (as Value.Dump would) to locate the basic block names. Note
 // that unnamed basic blocks are not given explicit labels during vanilla LLVM
 // assembly generation, but rather comments which include the basic block ID.
 // For this reason the "unnamed.patch" has been applied to the LLVM code base,
 // which ensures that all basic blocks are given explicit labels.
 
-package main
+package decomp
 
-// #include <stdio.h>
-//
-// void fflush_stderr(void) {
-// 	fflush(stderr);
-// }
+// // Declared by llvm-c/Core.h, and linked by the llvm.org/llvm/bindings/go/llvm
+// // package.
+// char *LLVMPrintValueToString(void *v);
+// void LLVMDisposeMessage(char *msg);
 import "C"
 
 import (
-	"io/ioutil"
+	"unsafe"
 
 	"github.com/llir/llvm/asm/lexer"
 	"github.com/llir/llvm/asm/token"
 	"github.com/mewkiz/pkg/errutil"
-	"golang.org/x/sys/unix"
 	"llvm.org/llvm/bindings/go/llvm"
 )
 
-// getTokens tokenizes the value dump of v and returns its tokens.
+// getTokens tokenizes the value dump of v and returns its tokens. Trailing
+// metadata attachments are dropped.
 func getTokens(v llvm.Value) ([]token.Token, error) {
 	s, err := hackDump(v)
 	if err != nil {
 		return nil, errutil.Err(err)
 	}
-	return lexer.ParseString(s), nil
+	return trimMetadata(lexer.ParseString(s)), nil
+}
+
+// trimMetadata returns the provided tokens without trailing metadata
+// attachments (e.g. branch weights and debug locations), which have no Go
+// equivalent.
+//
+// Examples:
+//    br i1 %c, label %1, label %2, !prof !0      ; br i1 %c, label %1, label %2
+//    %x = extractvalue {i32, i8} %s, 1, !dbg !12 ; %x = extractvalue {i32, i8} %s, 1
+func trimMetadata(tokens []token.Token) []token.Token {
+	for n := len(tokens); n >= 3; n = len(tokens) {
+		if tokens[n-3].Kind != token.Comma || tokens[n-2].Kind != token.MetadataVar || tokens[n-1].Kind != token.MetadataID {
+			break
+		}
+		tokens = tokens[:n-3]
+	}
+	return tokens
 }
 
 // getBBName returns the name (or ID if unnamed) of a basic block.
@@ -110,48 +126,18 @@ func getBBName(v llvm.Value) (string, error) {
 }
 
 // hackDump returns the value dump as a string.
+//
+// The value is printed to a string rather than capturing the output of
+// Value.Dump, as redirecting the standard error of the process would capture
+// the output of concurrent writers (e.g. when decompiling files in parallel) and
+// is inherited by child processes.
 func hackDump(v llvm.Value) (string, error) {
-	// Open temp file.
-	// TODO: Use an in-memory file instead of /tmp/x.
-	fd, err := unix.Open("/tmp/x", unix.O_WRONLY|unix.O_TRUNC|unix.O_CREAT, 0644)
-	if err != nil {
-		return "", errutil.Err(err)
-	}
-
-	// Store original stderr.
-	stderr, err := unix.Dup(2)
-	if err != nil {
-		return "", errutil.Err(err)
-	}
-
-	// Capture stderr and redirect its output to the temp file.
-	err = unix.Dup2(fd, 2)
-	if err != nil {
-		return "", errutil.Err(err)
-	}
-	err = unix.Close(fd)
-	if err != nil {
-		return "", errutil.Err(err)
-	}
-
-	// Dump value.
-	v.Dump()
-	C.fflush_stderr()
-
-	// Restore stderr.
-	err = unix.Dup2(stderr, 2)
-	if err != nil {
-		return "", errutil.Err(err)
-	}
-	err = unix.Close(stderr)
-	if err != nil {
-		return "", errutil.Err(err)
-	}
-
-	// Return content of temp file.
-	buf, err := ioutil.ReadFile("/tmp/x")
-	if err != nil {
-		return "", errutil.Err(err)
+	// The llvm.Value type wraps a single LLVMValueRef, which is not exported.
+	ref := *(*unsafe.Pointer)(unsafe.Pointer(&v))
+	if ref == nil {
+		return "", errutil.Newf("unable to dump nil value")
 	}
-	return string(buf), nil
+	cs := C.LLVMPrintValueToString(ref)
+	defer C.LLVMDisposeMessage(cs)
+	return C.GoString(cs) + "\n", nil
 }
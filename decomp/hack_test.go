@@ -0,0 +1,55 @@
+package decomp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/llir/llvm/asm/token"
+)
+
+func TestTrimMetadata(t *testing.T) {
+	var (
+		comma = token.Token{Kind: token.Comma, Val: ","}
+		prof  = token.Token{Kind: token.MetadataVar, Val: "prof"}
+		dbg   = token.Token{Kind: token.MetadataVar, Val: "dbg"}
+		md0   = token.Token{Kind: token.MetadataID, Val: "0"}
+		md12  = token.Token{Kind: token.MetadataID, Val: "12"}
+		one   = token.Token{Kind: token.Int, Val: "1"}
+		s     = token.Token{Kind: token.LocalVar, Val: "s"}
+	)
+	golden := []struct {
+		tokens []token.Token
+		want   []token.Token
+	}{
+		// %s, 1
+		{
+			tokens: []token.Token{s, comma, one},
+			want:   []token.Token{s, comma, one},
+		},
+		// %s, 1, !dbg !12
+		{
+			tokens: []token.Token{s, comma, one, comma, dbg, md12},
+			want:   []token.Token{s, comma, one},
+		},
+		// %s, 1, !prof !0, !dbg !12
+		{
+			tokens: []token.Token{s, comma, one, comma, prof, md0, comma, dbg, md12},
+			want:   []token.Token{s, comma, one},
+		},
+		// , !dbg !12
+		{
+			tokens: []token.Token{comma, dbg, md12},
+			want:   []token.Token{},
+		},
+		// %s, !12
+		{
+			tokens: []token.Token{s, comma, md12},
+			want:   []token.Token{s, comma, md12},
+		},
+	}
+	for i, g := range golden {
+		if got := trimMetadata(g.tokens); !reflect.DeepEqual(got, g.want) {
+			t.Errorf("i=%d: tokens mismatch; expected %v, got %v", i, g.want, got)
+		}
+	}
+}
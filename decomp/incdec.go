@@ -0,0 +1,94 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// simplifyIncDecs rewrites the assignments of the function body which
+// increment or decrement a variable by one into increment and decrement
+// statements. The recorded comment lines of each assignment are moved to its
+// replacement.
+//
+//    x = x + 1 ; x++
+//    x = 1 + x ; x++
+//    x = x - 1 ; x--
+func simplifyIncDecs(body *ast.BlockStmt, comments commentMap) {
+	simplify := func(stmt ast.Stmt) ast.Stmt {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok {
+			return stmt
+		}
+		if incDec := newIncDecStmt(assign); incDec != nil {
+			moveComments(comments, assign, incDec)
+			return incDec
+		}
+		return stmt
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			for i, stmt := range n.List {
+				n.List[i] = simplify(stmt)
+			}
+		case *ast.CaseClause:
+			for i, stmt := range n.Body {
+				n.Body[i] = simplify(stmt)
+			}
+		case *ast.LabeledStmt:
+			n.Stmt = simplify(n.Stmt)
+		case *ast.ForStmt:
+			if n.Post != nil {
+				n.Post = simplify(n.Post)
+			}
+		}
+		return true
+	})
+}
+
+// newIncDecStmt returns an increment or decrement statement equivalent to the
+// provided assignment, which adds one to or subtracts one from a variable, or
+// nil if the assignment has no such equivalent.
+//
+//    x = x + 1 ; x++
+//    x = 1 + x ; x++
+//    x = x - 1 ; x--
+func newIncDecStmt(assign *ast.AssignStmt) *ast.IncDecStmt {
+	if assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return nil
+	}
+	x, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	expr, ok := assign.Rhs[0].(*ast.BinaryExpr)
+	if !ok {
+		return nil
+	}
+	switch {
+	case isIdentName(expr.X, x.Name) && isOne(expr.Y):
+		switch expr.Op {
+		case token.ADD:
+			return &ast.IncDecStmt{X: x, Tok: token.INC}
+		case token.SUB:
+			return &ast.IncDecStmt{X: x, Tok: token.DEC}
+		}
+	case isOne(expr.X) && isIdentName(expr.Y, x.Name) && expr.Op == token.ADD:
+		return &ast.IncDecStmt{X: x, Tok: token.INC}
+	}
+	return nil
+}
+
+// isIdentName returns true if the provided expression is an identifier of the
+// given name, and false otherwise.
+func isIdentName(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// isOne returns true if the provided expression is the integer literal 1, and
+// false otherwise.
+func isOne(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	return ok && lit.Kind == token.INT && lit.Value == "1"
+}
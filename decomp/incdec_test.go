@@ -0,0 +1,69 @@
+package decomp
+
+import "testing"
+
+func TestSimplifyIncDecs(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Increment.
+		{
+			src:  "func f(x int32) {\nx = x + 1\ng(x)\n}",
+			want: "func f(x int32) {\n\tx++\n\tg(x)\n}",
+		},
+		// Increment with the literal on the left-hand side.
+		{
+			src:  "func f(x int32) {\nx = 1 + x\ng(x)\n}",
+			want: "func f(x int32) {\n\tx++\n\tg(x)\n}",
+		},
+		// Decrement.
+		{
+			src:  "func f(x int32) {\nx = x - 1\ng(x)\n}",
+			want: "func f(x int32) {\n\tx--\n\tg(x)\n}",
+		},
+		// Subtraction from one.
+		{
+			src:  "func f(x int32) {\nx = 1 - x\ng(x)\n}",
+			want: "func f(x int32) {\n\tx = 1 - x\n\tg(x)\n}",
+		},
+		// Literals other than one, and other variables.
+		{
+			src:  "func f(x, y int32) {\nx = x + 2\ny = x + 1\nx = x + 0x1\ng(x, y)\n}",
+			want: "func f(x, y int32) {\n\tx = x + 2\n\ty = x + 1\n\tx = x + 0x1\n\tg(x, y)\n}",
+		},
+		// Nested statements.
+		{
+			src:  "func f(x int32) {\nfor x < 10 {\nswitch x {\ncase 1:\nx = x + 1\n}\n}\nloop0:\nx = x - 1\n}",
+			want: "func f(x int32) {\n\tfor x < 10 {\n\t\tswitch x {\n\t\tcase 1:\n\t\t\tx++\n\t\t}\n\t}\nloop0:\n\tx--\n}",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		simplifyIncDecs(f.Body, nil)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+func TestSimplifyIncDecsComments(t *testing.T) {
+	f, err := parseTestFunc("func f(x int32) {\nx = x + 1\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assign := f.Body.List[0]
+	comments := commentMap{assign: {"from: %1 = add i32 %x, 1"}}
+	simplifyIncDecs(f.Body, comments)
+	incDec := f.Body.List[0]
+	if _, ok := comments[assign]; ok {
+		t.Errorf("comments of the replaced assignment not moved")
+	}
+	if got := comments[incDec]; len(got) != 1 || got[0] != "from: %1 = add i32 %x, 1" {
+		t.Errorf("comments mismatch; expected %q, got %q", "from: %1 = add i32 %x, 1", got)
+	}
+}
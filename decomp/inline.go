@@ -0,0 +1,177 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// inlineTemps substitutes the temporary variables of the function body (i.e.
+// local variable IDs with the given prefix, such as "_2") which are defined once
+// and used once by a later statement of the same statement list, with their
+// defining expression. The definition is removed, and its recorded comment
+// lines are moved to the statement of the use.
+//
+// Only expressions without side effects which don't read memory are inlined,
+// and only if the variables they read are not written between the definition
+// and the use.
+//
+//    // from:
+//    _6 := 3 * i
+//    _7 := x + _6
+//    x = _7
+//
+//    // to:
+//    x = x + 3*i
+func inlineTemps(body *ast.BlockStmt, prefix string, comments commentMap) {
+	// Variables which may be written indirectly, through their address or by
+	// function literals, are not tracked.
+	indirect := make(map[string]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				inspectIdents(n.X, func(ident *ast.Ident) {
+					indirect[ident.Name] = true
+				})
+			}
+		case *ast.FuncLit:
+			ast.Inspect(n.Body, func(n ast.Node) bool {
+				if assign, ok := n.(*ast.AssignStmt); ok {
+					for _, lhs := range assign.Lhs {
+						if ident, ok := lhs.(*ast.Ident); ok {
+							indirect[ident.Name] = true
+						}
+					}
+				}
+				return true
+			})
+		}
+		return true
+	})
+
+	for changed := true; changed; {
+		changed = false
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BlockStmt:
+				n.List = inlineStmts(body, n.List, prefix, indirect, comments, &changed)
+			case *ast.CaseClause:
+				n.Body = inlineStmts(body, n.Body, prefix, indirect, comments, &changed)
+			}
+			return true
+		})
+	}
+}
+
+// inlineStmts inlines the temporary variables defined by the provided statement
+// list, which is part of the given function body. The changed flag is set if
+// any temporary variable was inlined.
+func inlineStmts(body *ast.BlockStmt, stmts []ast.Stmt, prefix string, indirect map[string]bool, comments commentMap, changed *bool) []ast.Stmt {
+	for i := 0; i < len(stmts); i++ {
+		def, ok := stmts[i].(*ast.AssignStmt)
+		if !ok || def.Tok != token.DEFINE || len(def.Lhs) != 1 || len(def.Rhs) != 1 {
+			continue
+		}
+		ident, ok := def.Lhs[0].(*ast.Ident)
+		if !ok || !isTempName(ident.Name, prefix) || countIdents(body, ident.Name) != 2 {
+			continue
+		}
+		expr := def.Rhs[0]
+		if !isPure(expr) || readsMemory(expr) {
+			continue
+		}
+		reads := make(map[string]bool)
+		safe := true
+		inspectIdents(expr, func(ident *ast.Ident) {
+			reads[ident.Name] = true
+			safe = safe && !indirect[ident.Name]
+		})
+		if !safe {
+			continue
+		}
+
+		// Locate the use, which must not be preceded by writes to the variables
+		// read by the expression.
+		j := i + 1
+		for ; j < len(stmts); j++ {
+			if mentionsIdent(stmts[j], map[string]bool{ident.Name: true}) {
+				break
+			}
+			if writesAny(stmts[j], reads) {
+				safe = false
+				break
+			}
+		}
+		if !safe || j == len(stmts) || !canInline(stmts[j], ident.Name, reads) {
+			continue
+		}
+
+		// Substitute the use and remove the definition.
+		rewriteExprs(stmts[j], func(x ast.Expr) ast.Expr {
+			if use, ok := x.(*ast.Ident); ok && use.Name == ident.Name {
+				return expr
+			}
+			return x
+		})
+		moveComments(comments, def, stmts[j])
+		stmts = append(stmts[:i:i], stmts[i+1:]...)
+		*changed = true
+		i--
+	}
+	return stmts
+}
+
+// canInline returns true if the expression of the given temporary variable,
+// which reads the provided variables, may be substituted for its use within the
+// statement, and false otherwise. The expression is evaluated once before the
+// assignments of simple statements, while the use may be evaluated after writes
+// to the variables within compound statements (e.g. in loops) or function
+// literals.
+func canInline(stmt ast.Stmt, name string, reads map[string]bool) bool {
+	deferred := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			deferred = deferred || mentionsIdent(lit, map[string]bool{name: true})
+			return false
+		}
+		return !deferred
+	})
+	if deferred {
+		return false
+	}
+	switch stmt.(type) {
+	case *ast.AssignStmt, *ast.ExprStmt, *ast.ReturnStmt:
+		return true
+	}
+	return !writesAny(stmt, reads)
+}
+
+// isTempName returns true if the provided identifier is a local variable ID
+// with the given prefix (e.g. "_42"), and false otherwise.
+func isTempName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+		return false
+	}
+	for _, r := range name[len(prefix):] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// readsMemory returns true if the evaluation of the provided expression may
+// read memory, through pointer indirections, index expressions or selector
+// expressions, and false otherwise.
+func readsMemory(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.StarExpr, *ast.IndexExpr, *ast.SliceExpr, *ast.SelectorExpr:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,77 @@
+package decomp
+
+import (
+	"go/ast"
+	"testing"
+)
+
+func TestInlineTemps(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Chain of temporary variables.
+		{
+			src:  "func f(x, i int32) int32 {\n_6 := 3 * i\n_7 := x + _6\nx = _7\nreturn x\n}",
+			want: "func f(x, i int32) int32 {\n\tx = x + 3*i\n\treturn x\n}",
+		},
+		// Operands are parenthesized.
+		{
+			src:  "func f(x, y int32) int32 {\n_2 := x + y\nreturn _2 * 2\n}",
+			want: "func f(x, y int32) int32 {\n\treturn (x + y) * 2\n}",
+		},
+		// Use in a condition of a later statement.
+		{
+			src:  "func f(i int32) {\n_2 := i < 10\ng()\nif _2 {\ng()\n}\n}",
+			want: "func f(i int32) {\n\tg()\n\tif i < 10 {\n\t\tg()\n\t}\n}",
+		},
+		// Variables read by the expression are written before the use.
+		{
+			src:  "func f(i int32) int32 {\n_2 := i + 1\ni = 5\nreturn _2\n}",
+			want: "func f(i int32) int32 {\n\t_2 := i + 1\n\ti = 5\n\treturn _2\n}",
+		},
+		// Expressions with side effects or which read memory.
+		{
+			src:  "func f(p *int32) int32 {\n_2 := g()\n_3 := *p\nh()\nreturn _2 + _3\n}",
+			want: "func f(p *int32) int32 {\n\t_2 := g()\n\t_3 := *p\n\th()\n\treturn _2 + _3\n}",
+		},
+		// Temporary variables used twice, and named variables.
+		{
+			src:  "func f(x int32) int32 {\n_2 := x + 1\ny := x + 2\nreturn _2 * _2 * y\n}",
+			want: "func f(x int32) int32 {\n\t_2 := x + 1\n\ty := x + 2\n\treturn _2 * _2 * y\n}",
+		},
+		// Use within a loop which writes the variables read by the expression.
+		{
+			src:  "func f(i int32) {\n_2 := i + 1\nfor i < 10 {\ng(_2)\ni++\n}\n}",
+			want: "func f(i int32) {\n\t_2 := i + 1\n\tfor i < 10 {\n\t\tg(_2)\n\t\ti++\n\t}\n}",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		inlineTemps(f.Body, "_", nil)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+func TestInlineTempsComments(t *testing.T) {
+	f, err := parseTestFunc("func f(x int32) int32 {\n_2 := x + 1\nreturn _2\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, ret := f.Body.List[0], f.Body.List[1]
+	comments := commentMap{def: {"from: %2 = add i32 %x, 1"}, ret: {"from: ret i32 %2"}}
+	inlineTemps(f.Body, "_", comments)
+	want := []string{"from: %2 = add i32 %x, 1", "from: ret i32 %2"}
+	if got := comments[ret]; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("comments mismatch; expected %q, got %q", want, got)
+	}
+	if _, ok := comments[def]; ok {
+		t.Errorf("comments of removed statement %v not moved", def.(*ast.AssignStmt).Lhs[0])
+	}
+}
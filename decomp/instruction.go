@@ -0,0 +1,2125 @@
+package decomp
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	lltoken "github.com/llir/llvm/asm/token"
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// parseInst converts the provided LLVM IR instruction into an equivalent Go AST
+// node (a statement).
+func parseInst(inst llvm.Value) (ast.Stmt, error) {
+	Debug.Println("parseInst:")
+	Debug.Println("   nops:", inst.OperandsCount())
+	debugValue(inst)
+
+	// Instructions without a result (e.g. ret and store) and instructions with
+	// an optional result (e.g. call) are handled before the assignment
+	// operations.
+	opcode := inst.InstructionOpcode()
+	switch opcode {
+	case llvm.Ret:
+		return parseRetInst(inst)
+	case llvm.Store:
+		return parseStoreInst(inst)
+	case llvm.Call:
+		return parseCallInst(inst)
+	case llvm.Fence:
+		return parseFenceInst(inst)
+	}
+
+	// Assignment operation.
+	//    %foo = ...
+	if _, err := getResult(inst); err == nil {
+		switch opcode {
+		// Unary Operations
+		case llvm.FNeg:
+			return parseUnaryOp(inst, token.SUB)
+
+		// Binary Operations
+		case llvm.Add, llvm.FAdd:
+			return parseBinOp(inst, token.ADD)
+		case llvm.Sub, llvm.FSub:
+			return parseBinOp(inst, token.SUB)
+		case llvm.Mul, llvm.FMul:
+			return parseBinOp(inst, token.MUL)
+		case llvm.UDiv, llvm.SDiv, llvm.FDiv:
+			// TODO: Handle signed and unsigned div separately.
+			return parseBinOp(inst, token.QUO)
+		case llvm.URem, llvm.SRem, llvm.FRem:
+			// TODO: Handle signed and unsigned mod separately.
+			return parseBinOp(inst, token.REM)
+
+		// Bitwise Binary Operations
+		case llvm.Shl:
+			return parseBinOp(inst, token.SHL)
+		case llvm.LShr:
+			return parseShrInst(inst, false)
+		case llvm.AShr:
+			return parseShrInst(inst, true)
+		case llvm.And:
+			return parseBinOp(inst, token.AND)
+		case llvm.Or:
+			return parseBinOp(inst, token.OR)
+		case llvm.Xor:
+			return parseXorInst(inst)
+
+		// Memory Access and Addressing Operations
+		case llvm.Alloca:
+			return parseAllocaInst(inst)
+		case llvm.Load:
+			return parseLoadInst(inst)
+		case llvm.GetElementPtr:
+			return parseGEPInst(inst)
+		case llvm.AtomicCmpXchg:
+			return parseCmpXchgInst(inst)
+		case llvm.AtomicRMW:
+			return parseAtomicRMWInst(inst)
+
+		// Vector Operations
+		case llvm.ExtractElement:
+			return parseExtractElementInst(inst)
+		case llvm.InsertElement:
+			return parseInsertElementInst(inst)
+		case llvm.ShuffleVector:
+			return parseShuffleVectorInst(inst)
+
+		// Aggregate Operations
+		case llvm.ExtractValue:
+			return parseExtractValueInst(inst)
+		case llvm.InsertValue:
+			return parseInsertValueInst(inst)
+
+		// Conversion Operations
+		case llvm.Trunc, llvm.ZExt, llvm.SExt:
+			return parseCastInst(inst)
+		case llvm.FPToUI, llvm.FPToSI, llvm.UIToFP, llvm.SIToFP:
+			return parseCastInst(inst)
+		case llvm.PtrToInt, llvm.IntToPtr, llvm.BitCast:
+			return parseCastInst(inst)
+
+		// Other Operators
+		case llvm.ICmp, llvm.FCmp:
+			return parseCmpInst(inst)
+		case llvm.Select:
+			return parseSelectInst(inst)
+		case llvm.VAArg:
+			return parseVAArgInst(inst)
+		case llvm.Freeze:
+			return parseFreezeInst(inst)
+		}
+	}
+
+	return nil, errutil.Newf("support for LLVM IR instruction %q not yet implemented", prettyOpcode(opcode))
+}
+
+// parseUnaryOp converts the provided LLVM IR unary operation into an equivalent
+// Go AST node (an assignment statement with a unary expression on the right-
+// hand side).
+//
+// Syntax:
+//    <result> = fneg <type> <op1>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#unary-operations
+func parseUnaryOp(inst llvm.Value, op token.Token) (ast.Stmt, error) {
+	x, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{&ast.UnaryExpr{Op: op, X: x}}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseBinOp converts the provided LLVM IR binary operation into an equivalent
+// Go AST node (an assignment statement with a binary expression on the right-
+// hand side).
+//
+// Bitwise operations on i1 operands are translated into logical operations,
+// while arithmetic on i1 operands is not supported.
+//
+//    %r = and i1 %x, %y  ; _r := _x && _y
+//    %r = or i1 %x, %y   ; _r := _x || _y
+//
+// The nuw and nsw keywords of the binary operations, which produce a poison
+// value on overflow, are ignored as the operands are parsed from the operands
+// of the instruction rather than its tokens.
+//
+// The results of integer types without Go equivalent are wrapped around to
+// their bit width.
+//
+//    %r = add i24 %x, %y ; _r := (_x + _y) << 8 >> 8
+//
+// Syntax:
+//    <result> add [nuw] [nsw] <type> <op1>, <op2>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#binary-operations
+func parseBinOp(inst llvm.Value, op token.Token) (ast.Stmt, error) {
+	// Bitwise operations on i1 operands are translated into logical operations,
+	// as i1 is translated into bool.
+	if isBoolType(inst.Type()) {
+		switch op {
+		case token.AND:
+			op = token.LAND
+		case token.OR:
+			op = token.LOR
+		default:
+			return nil, errutil.Newf("support for arithmetic on i1 operands (%q) not yet implemented; i1 is translated into bool", prettyOpcode(inst.InstructionOpcode()))
+		}
+	}
+	x, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	y, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	var expr ast.Expr = &ast.BinaryExpr{X: x, Op: op, Y: y}
+	if typ := inst.Type(); typ.TypeKind() == llvm.IntegerTypeKind {
+		expr = wrapInt(expr, typ.IntTypeWidth())
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{expr}
+	// TODO: Use "=" instead of ":=" and let go-post and grind handle the ":=" to
+	// "=" propagation.
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseCmpInst converts the provided LLVM IR comparison instruction into an
+// equivalent Go AST node (an assignment statement with a binary expression on
+// the right-hand side).
+//
+// Go compares signed integers as signed and unsigned integers as unsigned, so
+// the operands of unsigned integer comparisons are converted to the unsigned Go
+// type of the operand width.
+//
+//    %r = icmp slt i32 %x, %y     ; _r := _x < _y
+//    %r = icmp ult i32 %x, %y     ; _r := uint32(_x) < uint32(_y)
+//    %r = icmp ult i32 %x, -1     ; _r := uint32(_x) < uint32(4294967295)
+//    %r = fcmp ord double %x, %y  ; _r := _x == _x && _y == _y
+//
+// Syntax:
+//    <result> = icmp <cond> <ty> <op1>, <op2>
+//    <result> = fcmp <cond> <ty> <op1>, <op2>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#icmp-instruction
+//    http://llvm.org/docs/LangRef.html#fcmp-instruction
+func parseCmpInst(inst llvm.Value) (ast.Stmt, error) {
+	cmp, unsigned, err := getCmpPred(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	parse := parseOperand
+	if typ := inst.Operand(0).Type(); unsigned && typ.TypeKind() == llvm.IntegerTypeKind {
+		parse = parseUnsignedOperand
+	}
+	x, err := parse(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	y, err := parse(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{cmp(x, y)}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseShrInst converts the provided LLVM IR shift right instruction into an
+// equivalent Go AST node (an assignment statement with a binary expression on
+// the right-hand side).
+//
+// Go uses arithmetic shifts for signed integers and logical shifts for unsigned
+// integers, so the first operand is converted to the signed or unsigned Go type
+// of the operand width based on the signed argument.
+//
+//    %r = ashr i32 %x, 2   ; _r := int32(_x) >> 2
+//    %r = lshr i32 %x, 2   ; _r := int32(uint32(_x) >> 2)
+//    %r = lshr i32 -8, 2   ; _r := int32(uint32(4294967288) >> 2)
+//
+// Syntax:
+//    <result> = lshr <ty> <op1>, <op2>
+//    <result> = ashr <ty> <op1>, <op2>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#lshr-instruction
+//    http://llvm.org/docs/LangRef.html#ashr-instruction
+func parseShrInst(inst llvm.Value, signed bool) (ast.Stmt, error) {
+	if isBoolType(inst.Type()) {
+		return nil, errutil.Newf("support for arithmetic on i1 operands (%q) not yet implemented; i1 is translated into bool", prettyOpcode(inst.InstructionOpcode()))
+	}
+
+	// Parse and validate tokens.
+	tokens, err := getTokens(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Locate the operand type, which may be preceded by the "exact" keyword.
+	var typTok *lltoken.Token
+	for i := range tokens {
+		if tokens[i].Kind == lltoken.Type {
+			typTok = &tokens[i]
+			break
+		}
+	}
+	if typTok == nil {
+		return nil, errutil.New("unable to locate operand type of shift instruction")
+	}
+	typ, err := getType(*typTok)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Parse operands.
+	parse := parseOperand
+	if !signed {
+		parse = parseUnsignedOperand
+	}
+	x, err := parse(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	y, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create and return the assignment statement.
+	var expr ast.Expr
+	if signed {
+		//    int32(_x) >> 2
+		expr = &ast.BinaryExpr{X: newConv(typ, x), Op: token.SHR, Y: y}
+	} else {
+		//    int32(uint32(_x) >> 2)
+		if _, ok := x.(*ast.BinaryExpr); ok {
+			x = &ast.ParenExpr{X: x}
+		}
+		expr = newConv(typ, &ast.BinaryExpr{X: x, Op: token.SHR, Y: y})
+		expr = wrapInt(expr, inst.Type().IntTypeWidth())
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{expr}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseUnsignedOperand converts the provided LLVM IR integer operand into an
+// equivalent Go AST expression node of the unsigned Go type of the operand
+// width. Constant operands are translated into unsigned literals, as negative
+// constants cannot be converted to unsigned types in Go. Operands of integer
+// types without Go equivalent are masked to their bit width, as their values are
+// sign-extended.
+//
+//    i32 %x ; uint32(_x)
+//    i32 -1 ; uint32(4294967295)
+//    i24 %x ; uint32(_x) & 16777215
+//    i1 %c  ; func() uint8 { if _c { return 1 }; return 0 }()
+func parseUnsignedOperand(op llvm.Value) (ast.Expr, error) {
+	width := op.Type().IntTypeWidth()
+	utyp, err := getIntType(width, false)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	if !op.IsAConstantInt().IsNil() && width > 1 {
+		val := op.ZExtValue()
+		if width < 64 {
+			val &= 1<<uint(width) - 1
+		}
+		lit := &ast.BasicLit{Kind: token.INT, Value: strconv.FormatUint(val, 10)}
+		return newConv(utyp, lit), nil
+	}
+	x, err := parseOperand(op)
+	if err != nil {
+		return nil, err
+	}
+	if width == 1 {
+		return newBoolConv(utyp, x, "1"), nil
+	}
+	if goWidth := getGoIntWidth(width); width > 1 && width < goWidth {
+		//    i24 %x ; uint32(_x) & 16777215
+		mask := &ast.BasicLit{Kind: token.INT, Value: strconv.FormatUint(1<<uint(width)-1, 10)}
+		return &ast.BinaryExpr{X: newConv(utyp, x), Op: token.AND, Y: mask}, nil
+	}
+	return newConv(utyp, x), nil
+}
+
+// newBoolConv returns a conversion of the boolean x to the given numeric type,
+// which evaluates to val if x is true and to zero otherwise.
+//
+//    func() int32 {
+//       if _c {
+//          return 1
+//       }
+//       return 0
+//    }()
+func newBoolConv(typ ast.Expr, x ast.Expr, val string) *ast.CallExpr {
+	lit := &ast.BasicLit{Kind: token.INT, Value: val}
+	ifStmt := &ast.IfStmt{
+		Cond: x,
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{lit}}}},
+	}
+	zero := &ast.BasicLit{Kind: token.INT, Value: "0"}
+	fn := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{ifStmt, &ast.ReturnStmt{Results: []ast.Expr{zero}}}},
+	}
+	return &ast.CallExpr{Fun: fn}
+}
+
+// newConv returns a new conversion expression of x to the given type.
+func newConv(typ ast.Expr, x ast.Expr) *ast.CallExpr {
+	if _, ok := typ.(*ast.StarExpr); ok {
+		// Pointer types must be parenthesized within conversions.
+		//    (*int32)(x)
+		typ = &ast.ParenExpr{X: typ}
+	}
+	return &ast.CallExpr{Fun: typ, Args: []ast.Expr{x}}
+}
+
+// parseXorInst converts the provided LLVM IR xor instruction into an equivalent
+// Go AST node (an assignment statement with a binary expression or a unary
+// expression on the right-hand side).
+//
+// The xor instruction is used by LLVM to express bitwise complement and logical
+// negation, which are translated into the equivalent unary Go expressions.
+//
+//    %r = xor i32 %x, -1   ; _r := ^_x
+//    %r = xor i1 %x, true  ; _r := !_x
+//    %r = xor i1 %x, %y    ; _r := _x != _y
+//
+// Syntax:
+//    <result> = xor <ty> <op1>, <op2>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#xor-instruction
+func parseXorInst(inst llvm.Value) (ast.Stmt, error) {
+	x, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	y, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	var expr ast.Expr
+	switch {
+	case isAllOnes(y):
+		expr = &ast.UnaryExpr{Op: token.XOR, X: x}
+	case isTrue(y):
+		expr = &ast.UnaryExpr{Op: token.NOT, X: x}
+	case isBoolType(inst.Type()):
+		expr = &ast.BinaryExpr{X: x, Op: token.NEQ, Y: y}
+	default:
+		expr = &ast.BinaryExpr{X: x, Op: token.XOR, Y: y}
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{expr}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// isBoolType returns true if the provided LLVM IR type is i1, which is
+// translated into bool, and false otherwise.
+func isBoolType(typ llvm.Type) bool {
+	return typ.TypeKind() == llvm.IntegerTypeKind && typ.IntTypeWidth() == 1
+}
+
+// isAllOnes returns true if the provided expression is the integer constant -1
+// (i.e. all bits set), and false otherwise.
+func isAllOnes(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	return ok && lit.Kind == token.INT && lit.Value == "-1"
+}
+
+// isTrue returns true if the provided expression is the boolean constant true,
+// and false otherwise.
+func isTrue(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "true"
+}
+
+// parseAllocaInst converts the provided LLVM IR alloca instruction into an
+// equivalent Go AST node (a variable declaration or an assignment statement
+// with a new call on the right-hand side).
+//
+// Syntax:
+//    <result> = alloca <type>
+//    <result> = alloca <type>, align <alignment>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#alloca-instruction
+func parseAllocaInst(inst llvm.Value) (ast.Stmt, error) {
+	// Parse the allocated type.
+	// TODO: Handle the optional number of elements (e.g. "alloca i32, i32 4").
+	typ, err := getGoType(inst.Type().ElementType())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// The result of an alloca instruction is a pointer to the allocated memory,
+	// which is modeled by either a Go variable or a call to new, depending on
+	// the Ptr option.
+	//
+	//    var _p int32
+	//    _p := new(int32)
+	return parseAllocaMem(inst, typ)
+}
+
+// parseLoadInst converts the provided LLVM IR load instruction into an
+// equivalent Go AST node (an assignment statement with a pointer dereference on
+// the right-hand side).
+//
+// Syntax:
+//    <result> = load <ty>* <pointer>
+//    <result> = load <ty>, <ty>* <pointer>
+//    <result> = load <ty>, ptr <pointer>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#load-instruction
+func parseLoadInst(inst llvm.Value) (ast.Stmt, error) {
+	// The pointer operand is located using the in-memory representation rather
+	// than the tokens, as the token layout differs between typed pointers (e.g.
+	// "i32*") and opaque pointers (e.g. "ptr").
+	mem, err := parseMem(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{mem}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseStoreInst converts the provided LLVM IR store instruction into an
+// equivalent Go AST node (an assignment statement with a pointer dereference on
+// the left-hand side).
+//
+// Syntax:
+//    store <ty> <value>, <ty>* <pointer>
+//    store <ty> <value>, ptr <pointer>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#store-instruction
+func parseStoreInst(inst llvm.Value) (ast.Stmt, error) {
+	val, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	mem, err := parseMem(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	lhs := []ast.Expr{mem}
+	rhs := []ast.Expr{val}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.ASSIGN, Rhs: rhs}, nil
+}
+
+// parseGEPInst converts the provided LLVM IR getelementptr instruction into an
+// equivalent Go AST node (an assignment statement with an address-of expression
+// on the right-hand side).
+//
+// Syntax:
+//    <result> = getelementptr [inbounds] <ty>* <ptrval>{, <ty> <idx>}*
+//    <result> = getelementptr [inbounds] <ty>, <ty>* <ptrval>{, <ty> <idx>}*
+//
+// Examples:
+//    %e = getelementptr i32, i32* %arr, i64 %i                    ; _e := &_arr[_i]
+//    %f = getelementptr %struct.foo, %struct.foo* %p, i32 0, i32 1 ; _f := &_p._1
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#getelementptr-instruction
+func parseGEPInst(inst llvm.Value) (ast.Stmt, error) {
+	// Parse and validate tokens.
+	tokens, err := getTokens(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	if len(tokens) < 4 {
+		return nil, errutil.Newf("unable to parse getelementptr instruction; expected >= 4 tokens, got %d", len(tokens))
+	}
+	if tok := tokens[2]; tok.Kind != lltoken.KwGetelementptr {
+		return nil, errutil.Newf(`invalid getelementptr instruction; expected "getelementptr" token, got %q`, tok)
+	}
+
+	// Pointers to string constants are represented by Go strings.
+	//    %s = getelementptr [6 x i8]* @.str, i64 0, i64 0 ; _s := "hello"
+	if lit, ok := getStringLit(inst); ok {
+		result, err := getResult(inst)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		return &ast.AssignStmt{Lhs: []ast.Expr{result}, Tok: token.DEFINE, Rhs: []ast.Expr{lit}}, nil
+	}
+
+	// Parse the pointer operand and the first index. The first index steps
+	// through the pointer operand, and may be omitted if it is zero and followed
+	// by additional indices since Go implicitly dereferences pointers to arrays
+	// and structs.
+	//
+	//    getelementptr [4 x i32]* %a, i64 0, i64 %i ; &_a[_i]
+	if inst.OperandsCount() < 2 {
+		return nil, errutil.Newf("invalid getelementptr instruction; expected >= 2 operands, got %d", inst.OperandsCount())
+	}
+	x, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	if addr, ok := x.(*ast.UnaryExpr); ok && addr.Op == token.AND {
+		// Memory modeled as a Go value is indexed directly.
+		//    &_a ; _a
+		x = addr.X
+	}
+	index, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	if !isZero(index) || inst.OperandsCount() == 2 {
+		x = &ast.IndexExpr{X: x, Index: index}
+	}
+
+	// Parse the remaining indices, which step into arrays and structs. The
+	// types are stepped through based on the LLVM IR source element type, as
+	// the Go type of a named structure is only an identifier.
+	//
+	//    getelementptr %struct.S* %p, i64 0, i32 1, i64 2 ; &_p._1[2]
+	typ := inst.Operand(0).Type().ElementType()
+	for i := 2; i < inst.OperandsCount(); i++ {
+		switch typ.TypeKind() {
+		case llvm.ArrayTypeKind, llvm.VectorTypeKind:
+			//    _a[_i]
+			index, err := parseOperand(inst.Operand(i))
+			if err != nil {
+				return nil, err
+			}
+			x = &ast.IndexExpr{X: x, Index: index}
+			typ = typ.ElementType()
+		case llvm.StructTypeKind:
+			//    _p._1
+			index := inst.Operand(i)
+			if index.IsAConstantInt().IsNil() {
+				return nil, errutil.New("invalid struct index; expected integer constant")
+			}
+			field := int(index.ZExtValue())
+			fields := typ.StructElementTypes()
+			if field >= len(fields) {
+				return nil, errutil.Newf("invalid struct index; expected < %d, got %d", len(fields), field)
+			}
+			x = &ast.SelectorExpr{X: x, Sel: getFieldName(field)}
+			typ = fields[field]
+		default:
+			return nil, errutil.Newf("support for getelementptr index into %v not yet implemented", typ)
+		}
+	}
+
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: x}}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// isZero returns true if the provided expression is the integer constant 0, and
+// false otherwise.
+func isZero(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	return ok && lit.Kind == token.INT && lit.Value == "0"
+}
+
+// parseCallInst converts the provided LLVM IR call instruction into an
+// equivalent Go AST node (an expression statement for void calls and an
+// assignment statement with a call expression on the right-hand side
+// otherwise).
+//
+// Syntax:
+//    call void @foo(<ty> <arg>, ...)
+//    <result> = call <ty> @foo(<ty> <arg>, ...)
+//    <result> = invoke <ty> @foo(<ty> <arg>, ...) to label <normal> unwind label <exception>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#call-instruction
+//    http://llvm.org/docs/LangRef.html#invoke-instruction
+func parseCallInst(inst llvm.Value) (ast.Stmt, error) {
+	// Parse and validate tokens.
+	tokens, err := getTokens(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Locate the callee.
+	// TODO: Add support for indirect calls (e.g. "call void %fn()").
+	var calleeName string
+	for _, tok := range tokens {
+		if tok.Kind == lltoken.GlobalVar {
+			calleeName = tok.Val
+			break
+		}
+	}
+	if len(calleeName) == 0 {
+		return nil, errutil.New("unable to locate callee of call instruction")
+	}
+
+	// Calls through global aliases are replaced with calls to the aliasee.
+	//    @f = alias void ()* @g
+	//    call void @f() ; g()
+	if v := inst.Operand(inst.OperandsCount() - 1); !v.IsAGlobalValue().IsNil() {
+		switch {
+		case isIFunc(v):
+			return nil, errutil.Newf("support for calls to ifunc %q not yet implemented", calleeName)
+		case !v.IsAGlobalAlias().IsNil():
+			aliasee := getAliasee(v)
+			if aliasee.IsAFunction().IsNil() {
+				return nil, errutil.Newf("unable to locate function aliased by %q", calleeName)
+			}
+			calleeName = aliasee.Name()
+		}
+	}
+	callee := ast.NewIdent(globalName(parentFunc(inst).GlobalParent(), calleeName))
+
+	// The variable argument handling intrinsics have no Go equivalent, as the
+	// variadic arguments are accessed through the variadic parameter of the Go
+	// function.
+	//    call void @llvm.va_start(i8* %ap)
+	if isVAIntrinsic(calleeName) {
+		return nil, nil
+	}
+
+	// Parse arguments; the callee is the last operand of the call instruction,
+	// and is preceded by the normal and unwind destinations of invoke
+	// instructions.
+	nargs := inst.OperandsCount() - 1
+	if inst.InstructionOpcode() == llvm.Invoke {
+		nargs -= 2
+	}
+	call := &ast.CallExpr{Fun: callee}
+	for i := 0; i < nargs; i++ {
+		arg, err := parseOperand(inst.Operand(i))
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+	}
+
+	// Create and return an expression statement for void calls.
+	//    foo(_a, _b)
+	result, err := getResult(inst)
+	if err != nil {
+		return &ast.ExprStmt{X: call}, nil
+	}
+
+	// Create and return an assignment statement.
+	//    _r := foo(_a, _b)
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{call}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// isVAIntrinsic reports whether the provided callee name denotes one of the
+// variable argument handling intrinsics (e.g. "llvm.va_start").
+func isVAIntrinsic(name string) bool {
+	switch name {
+	case "llvm.va_start", "llvm.va_end", "llvm.va_copy":
+		return true
+	}
+	return false
+}
+
+// parseVAArgInst converts the provided LLVM IR va_arg instruction into an
+// equivalent Go AST node (an assignment statement with a type assertion of a
+// variadic argument on the right-hand side).
+//
+// The va_arg instructions of a function are assumed to access consecutive
+// variadic arguments, in the order of appearance; which is approximate but
+// sufficient for printf-style functions.
+//
+//    %x = va_arg i8** %ap, i32  ; _x := args[0].(int32)
+//
+// Syntax:
+//    <resultval> = va_arg <va_list*> <arglist>, <argty>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#va-arg-instruction
+func parseVAArgInst(inst llvm.Value) (ast.Stmt, error) {
+	typ, err := getGoType(inst.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	fn := parentFunc(inst)
+	index := 0
+	if ctx := getContext(fn); ctx != nil {
+		index = ctx.nextVAArg
+		ctx.nextVAArg++
+	}
+	arg := &ast.IndexExpr{
+		X:     ast.NewIdent(varArgsName(fn)),
+		Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(index)},
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{&ast.TypeAssertExpr{X: arg, Type: typ}}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseFreezeInst converts the provided LLVM IR freeze instruction into an
+// equivalent Go AST node (an assignment statement). Go has no notion of undef
+// and poison values, so freeze is translated into a plain assignment.
+//
+//    %r = freeze i32 %x  ; _r := _x
+//
+// Syntax:
+//    <result> = freeze ty <val>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#freeze-instruction
+func parseFreezeInst(inst llvm.Value) (ast.Stmt, error) {
+	x, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{x}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseSelectInst converts the provided LLVM IR select instruction into an
+// equivalent Go AST node (an assignment statement with a call to a function
+// literal on the right-hand side).
+//
+// Go has no conditional operator, so the selection is expressed using an
+// if-statement within an immediately invoked function literal.
+//
+//    _r := func() int32 {
+//       if _c {
+//          return _a
+//       }
+//       return _b
+//    }()
+//
+// Syntax:
+//    <result> = select i1 <cond>, <ty> <val1>, <ty> <val2>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#select-instruction
+func parseSelectInst(inst llvm.Value) (ast.Stmt, error) {
+	// Parse the type of the selected values.
+	typ, err := getGoType(inst.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Parse operands.
+	cond, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	x, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	y, err := parseOperand(inst.Operand(2))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create function literal.
+	ifStmt := &ast.IfStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{x}}}},
+	}
+	fn := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{ifStmt, &ast.ReturnStmt{Results: []ast.Expr{y}}}},
+	}
+
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{&ast.CallExpr{Fun: fn}}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseExtractElementInst converts the provided LLVM IR extractelement
+// instruction into an equivalent Go AST node (an assignment statement with an
+// index expression on the right-hand side). Vectors are represented by Go
+// arrays.
+//
+//    %r = extractelement <4 x i32> %v, i32 %i  ; _r := _v[_i]
+//
+// Syntax:
+//    <result> = extractelement <n x <ty>> <val>, <ty2> <idx>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#extractelement-instruction
+func parseExtractElementInst(inst llvm.Value) (ast.Stmt, error) {
+	x, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	index, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{&ast.IndexExpr{X: x, Index: index}}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseInsertElementInst converts the provided LLVM IR insertelement
+// instruction into an equivalent Go AST node (an assignment statement with a
+// call to a function literal on the right-hand side).
+//
+// The vector operand may be used after the insertion, so the element is
+// assigned to a copy of the vector within an immediately invoked function
+// literal.
+//
+//    _r := func() [4]int32 {
+//       _r := _v
+//       _r[_i] = _e
+//       return _r
+//    }()
+//
+// Syntax:
+//    <result> = insertelement <n x <ty>> <val>, <ty> <elt>, <ty2> <idx>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#insertelement-instruction
+func parseInsertElementInst(inst llvm.Value) (ast.Stmt, error) {
+	typ, err := getGoType(inst.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Parse operands.
+	vec, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	val, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	index, err := parseOperand(inst.Operand(2))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Create function literal.
+	stmts := []ast.Stmt{
+		&ast.AssignStmt{Lhs: []ast.Expr{result}, Tok: token.DEFINE, Rhs: []ast.Expr{vec}},
+		&ast.AssignStmt{Lhs: []ast.Expr{&ast.IndexExpr{X: result, Index: index}}, Tok: token.ASSIGN, Rhs: []ast.Expr{val}},
+		&ast.ReturnStmt{Results: []ast.Expr{result}},
+	}
+	fn := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+		},
+		Body: &ast.BlockStmt{List: stmts},
+	}
+
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{&ast.CallExpr{Fun: fn}}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseShuffleVectorInst converts the provided LLVM IR shufflevector
+// instruction into an equivalent Go AST node (an assignment statement with a
+// composite literal on the right-hand side). The shuffle is unrolled into one
+// element per mask index, where indices beyond the length of the first vector
+// select elements of the second vector.
+//
+//    %r = shufflevector <2 x i32> %a, <2 x i32> %b, <2 x i32> <i32 3, i32 0>
+//    _r := [2]int32{_b[1], _a[0]}
+//
+// Syntax:
+//    <result> = shufflevector <n x <ty>> <v1>, <n x <ty>> <v2>, <m x i32> <mask>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#shufflevector-instruction
+func parseShuffleVectorInst(inst llvm.Value) (ast.Stmt, error) {
+	typ, err := getGoType(inst.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	mask, err := getShuffleMask(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Parse operands.
+	x, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	y, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create composite literal.
+	n := inst.Operand(0).Type().VectorSize()
+	lit := &ast.CompositeLit{Type: typ}
+	for _, index := range mask {
+		vec := x
+		if index >= n {
+			vec = y
+			index -= n
+		}
+		elem := &ast.IndexExpr{X: vec, Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(index)}}
+		lit.Elts = append(lit.Elts, elem)
+	}
+
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{lit}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// getShuffleMask returns the mask indices of the provided shufflevector
+// instruction, which are located at the end of its tokens. Undefined mask
+// elements select the first element.
+//
+// Syntax:
+//    <m x i32> <i32 <idx>, ...>
+//    <m x i32> zeroinitializer
+func getShuffleMask(inst llvm.Value) ([]int, error) {
+	tokens, err := getTokens(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	m := inst.Type().VectorSize()
+	if len(tokens) > 0 && tokens[len(tokens)-1].Kind == lltoken.KwZeroinitializer {
+		//    zeroinitializer
+		return make([]int, m), nil
+	}
+
+	//    <i32 3, i32 0>
+	end := len(tokens) - 1
+	if end < 0 || tokens[end].Kind != lltoken.Greater {
+		return nil, errutil.New(`unable to parse shuffle mask; expected ">" token`)
+	}
+	start := end - 3*m
+	if start < 0 || tokens[start].Kind != lltoken.Less {
+		return nil, errutil.New(`unable to parse shuffle mask; expected "<" token`)
+	}
+	var mask []int
+	for i := start + 2; i < end; i += 3 {
+		switch tok := tokens[i]; tok.Kind {
+		case lltoken.Int:
+			index, err := strconv.Atoi(tok.Val)
+			if err != nil {
+				return nil, errutil.Err(err)
+			}
+			mask = append(mask, index)
+		case lltoken.KwUndef:
+			mask = append(mask, 0)
+		default:
+			return nil, errutil.Newf("invalid shuffle mask index; expected integer constant, got %q", tok)
+		}
+	}
+	return mask, nil
+}
+
+// parseExtractValueInst converts the provided LLVM IR extractvalue instruction
+// into an equivalent Go AST node (an assignment statement with a selector or
+// index expression on the right-hand side).
+//
+//    %r = extractvalue {i32, i32} %agg, 1  ; _r := _agg._1
+//    %r = extractvalue [2 x i32] %agg, 1   ; _r := _agg[1]
+//
+// Syntax:
+//    <result> = extractvalue <aggregate type> <val>, <idx>{, <idx>}*
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#extractvalue-instruction
+func parseExtractValueInst(inst llvm.Value) (ast.Stmt, error) {
+	indices, err := getAggregateIndices(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	agg := inst.Operand(0)
+	x, err := parseOperand(agg)
+	if err != nil {
+		return nil, err
+	}
+	elem, err := getAggregateElem(x, agg.Type(), indices)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{elem}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseInsertValueInst converts the provided LLVM IR insertvalue instruction
+// into an equivalent Go AST node (an assignment statement with a call to a
+// function literal on the right-hand side).
+//
+// The aggregate operand may be used after the insertion, so the element is
+// assigned to a copy of the aggregate within an immediately invoked function
+// literal.
+//
+//    _r := func() struct{ _0 int32; _1 int32 } {
+//       _r := _agg
+//       _r._1 = _v
+//       return _r
+//    }()
+//
+// Syntax:
+//    <result> = insertvalue <aggregate type> <val>, <ty> <elt>, <idx>{, <idx>}*
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#insertvalue-instruction
+func parseInsertValueInst(inst llvm.Value) (ast.Stmt, error) {
+	typ, err := getGoType(inst.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	indices, err := getAggregateIndices(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Parse operands.
+	agg, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	val, err := parseOperand(inst.Operand(1))
+	if err != nil {
+		return nil, err
+	}
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	elem, err := getAggregateElem(result, inst.Type(), indices)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Create function literal.
+	stmts := []ast.Stmt{
+		&ast.AssignStmt{Lhs: []ast.Expr{result}, Tok: token.DEFINE, Rhs: []ast.Expr{agg}},
+		&ast.AssignStmt{Lhs: []ast.Expr{elem}, Tok: token.ASSIGN, Rhs: []ast.Expr{val}},
+		&ast.ReturnStmt{Results: []ast.Expr{result}},
+	}
+	fn := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+		},
+		Body: &ast.BlockStmt{List: stmts},
+	}
+
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{&ast.CallExpr{Fun: fn}}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// getAggregateIndices returns the constant indices of the provided extractvalue
+// or insertvalue instruction, which are located at the end of its tokens.
+//
+// Syntax:
+//    <result> = extractvalue <aggregate type> <val>, <idx>{, <idx>}*
+//    <result> = insertvalue <aggregate type> <val>, <ty> <elt>, <idx>{, <idx>}*
+func getAggregateIndices(inst llvm.Value) ([]int, error) {
+	tokens, err := getTokens(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Each index is an integer token preceded by a comma, while an integer
+	// element (e.g. "i32 1") is preceded by its type.
+	var indices []int
+	for i := len(tokens) - 1; i > 0; i -= 2 {
+		if tokens[i].Kind != lltoken.Int || tokens[i-1].Kind != lltoken.Comma {
+			break
+		}
+		index, err := strconv.Atoi(tokens[i].Val)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		indices = append([]int{index}, indices...)
+	}
+	if len(indices) == 0 {
+		return nil, errutil.Newf("unable to locate indices of %s instruction", prettyOpcode(inst.InstructionOpcode()))
+	}
+	return indices, nil
+}
+
+// getAggregateElem returns the element of the aggregate x with the given type at
+// the provided indices (a chain of selector and index expressions).
+func getAggregateElem(x ast.Expr, typ llvm.Type, indices []int) (ast.Expr, error) {
+	for _, index := range indices {
+		switch typ.TypeKind() {
+		case llvm.StructTypeKind:
+			//    _agg._1
+			fields := typ.StructElementTypes()
+			if index >= len(fields) {
+				return nil, errutil.Newf("invalid struct index %d; expected < %d", index, len(fields))
+			}
+			x = &ast.SelectorExpr{X: x, Sel: getFieldName(index)}
+			typ = fields[index]
+		case llvm.ArrayTypeKind, llvm.VectorTypeKind:
+			//    _agg[1]
+			lit := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(index)}
+			x = &ast.IndexExpr{X: x, Index: lit}
+			typ = typ.ElementType()
+		default:
+			return nil, errutil.Newf("invalid aggregate index into type %v", typ)
+		}
+	}
+	return x, nil
+}
+
+// parseCastInst converts the provided LLVM IR conversion instruction into an
+// equivalent Go AST node (an assignment statement with a conversion expression
+// on the right-hand side).
+//
+//    %r = trunc i32 %x to i8       ; _r := int8(_x)
+//    %r = zext i8 %x to i32        ; _r := int32(uint8(_x))
+//    %r = zext i8 -1 to i32        ; _r := int32(uint8(255))
+//    %r = sext i8 %x to i32        ; _r := int32(_x)
+//    %r = sitofp i32 %x to double  ; _r := float64(_x)
+//    %r = uitofp i32 %x to double  ; _r := float64(uint32(_x))
+//    %r = uitofp i32 -1 to double  ; _r := float64(uint32(4294967295))
+//    %r = fptosi double %x to i32  ; _r := int32(_x)
+//    %r = fptoui double %x to i32  ; _r := int32(uint32(_x))
+//    %r = ptrtoint i32* %p to i64  ; _r := int64(uintptr(unsafe.Pointer(_p)))
+//    %r = inttoptr i64 %x to i32*  ; _r := (*int32)(unsafe.Pointer(uintptr(_x)))
+//    %r = bitcast i8* %p to i32*   ; _r := _p
+//
+// Conversions between pointers and integers always make use of unsafe.Pointer,
+// as Go has no other conversions between them. Pointer casts make use of
+// unsafe.Pointer if the Unsafe option is set.
+//
+//    %r = bitcast i8* %p to i32*   ; _r := (*int32)(unsafe.Pointer(_p))
+//
+// Syntax:
+//    <result> = trunc <ty> <value> to <ty2>
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#conversion-operations
+func parseCastInst(inst llvm.Value) (ast.Stmt, error) {
+	if isBoolType(inst.Operand(0).Type()) || isBoolType(inst.Type()) {
+		return parseBoolCastInst(inst)
+	}
+
+	// Locate the destination type.
+	//    <ty> <value> to <ty2>
+	dst, err := getGoType(inst.Type())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	opts := getOptions(inst)
+
+	// Parse operand. Go zero-extends unsigned integers when converting them to
+	// wider integer types, so the operand of zext is converted through the
+	// unsigned Go type of the source width first. Likewise, the operand of
+	// uitofp is interpreted as unsigned.
+	parse := parseOperand
+	switch inst.InstructionOpcode() {
+	case llvm.ZExt, llvm.UIToFP:
+		parse = parseUnsignedOperand
+	}
+	x, err := parse(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+
+	// Create conversion expression.
+	switch opcode := inst.InstructionOpcode(); opcode {
+	case llvm.Trunc, llvm.SExt, llvm.SIToFP, llvm.FPToSI:
+		// Go sign-extends signed integers when converting them to wider integer
+		// types.
+	case llvm.ZExt, llvm.UIToFP:
+		// Converted through the unsigned Go type of the source width above.
+	case llvm.FPToUI:
+		// Convert the floating-point operand to the unsigned Go type of the
+		// destination width first, so that rounding and range match LLVM.
+		utyp, err := getIntType(inst.Type().IntTypeWidth(), false)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		x = newConv(utyp, x)
+	case llvm.PtrToInt:
+		x = newConv(newIdent("uintptr"), newUnsafePointer(x))
+	case llvm.IntToPtr:
+		x = newUnsafePointer(newConv(newIdent("uintptr"), x))
+	case llvm.BitCast:
+		if !opts.Unsafe {
+			// Pointer casts are plain assignments unless unsafe.Pointer
+			// conversions are enabled.
+			// TODO: Add support for bitcasts between non-pointer types.
+			dst = nil
+			break
+		}
+		x = unsafePointer(opts, x)
+	default:
+		return nil, errutil.Newf("support for conversion instruction %q not yet implemented", prettyOpcode(opcode))
+	}
+	if dst != nil {
+		x = newConv(dst, x)
+	}
+	switch opcode := inst.InstructionOpcode(); {
+	case opcode == llvm.SExt, opcode == llvm.ZExt:
+		// The value of the operand fits the bit width.
+	case inst.Type().TypeKind() == llvm.IntegerTypeKind:
+		//    %r = trunc i32 %x to i24 ; _r := int32(_x) << 8 >> 8
+		x = wrapInt(x, inst.Type().IntTypeWidth())
+	}
+
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{x}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// parseBoolCastInst converts the provided LLVM IR conversion instruction from or
+// to i1 into an equivalent Go AST node. Go has no conversions between booleans
+// and numbers, so booleans are converted by newBoolConv, and conversions to i1
+// are translated into comparisons with zero.
+//
+//    %r = zext i1 %c to i32        ; _r := func() int32 { if _c { return 1 }; return 0 }()
+//    %r = sext i1 %c to i32        ; _r := func() int32 { if _c { return -1 }; return 0 }()
+//    %r = uitofp i1 %c to double   ; _r := func() float64 { if _c { return 1 }; return 0 }()
+//    %r = trunc i32 %x to i1       ; _r := _x&1 != 0
+//    %r = fptoui double %x to i1   ; _r := _x != 0
+func parseBoolCastInst(inst llvm.Value) (ast.Stmt, error) {
+	x, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, err
+	}
+	switch opcode := inst.InstructionOpcode(); opcode {
+	case llvm.ZExt, llvm.UIToFP, llvm.SExt, llvm.SIToFP:
+		typ, err := getGoType(inst.Type())
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		val := "1"
+		if opcode == llvm.SExt || opcode == llvm.SIToFP {
+			// The sign bit of i1 is set for true.
+			val = "-1"
+		}
+		x = newBoolConv(typ, x, val)
+	case llvm.Trunc:
+		one := &ast.BasicLit{Kind: token.INT, Value: "1"}
+		x = &ast.BinaryExpr{X: &ast.BinaryExpr{X: x, Op: token.AND, Y: one}, Op: token.NEQ, Y: &ast.BasicLit{Kind: token.INT, Value: "0"}}
+	case llvm.FPToUI, llvm.FPToSI:
+		x = &ast.BinaryExpr{X: x, Op: token.NEQ, Y: &ast.BasicLit{Kind: token.INT, Value: "0"}}
+	case llvm.BitCast:
+		// i1 to i1.
+	default:
+		return nil, errutil.Newf("support for conversion instruction %q of type i1 not yet implemented", prettyOpcode(opcode))
+	}
+
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{x}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
+
+// unsafePointer returns a conversion of x to unsafe.Pointer if the Unsafe option
+// is set, and x otherwise.
+func unsafePointer(opts *Options, x ast.Expr) ast.Expr {
+	if !opts.Unsafe {
+		return x
+	}
+	return newUnsafePointer(x)
+}
+
+// newUnsafePointer returns a conversion of x to unsafe.Pointer.
+func newUnsafePointer(x ast.Expr) ast.Expr {
+	typ := newPkgSelector("unsafe", "Pointer")
+	return newConv(typ, x)
+}
+
+// parseOperand converts the provided LLVM IR operand into an equivalent Go AST
+// expression node (a basic literal, a composite literal or an identifier).
+//
+// Syntax:
+//    i32 1
+//    %foo = ...
+func parseOperand(op llvm.Value) (ast.Expr, error) {
+	// TODO: Support *BasicLit, *CompositeLit.
+
+	// Create and return a constant operand based on the in-memory
+	// representation, which is independent of how the sign and digits of the
+	// value are tokenized.
+	//    i32 -5
+	//    i64 9999999999
+	//    i8* null
+	switch {
+	case !op.IsAConstantInt().IsNil():
+		return getIntLit(op)
+	case !op.IsAConstantPointerNull().IsNil():
+		return newIdent("nil"), nil
+	}
+
+	// Create and return the zero value of the type of undef and poison values.
+	//    i32 undef  ; 0
+	//    i32 poison ; 0
+	//
+	// Poison values are undef values in the in-memory representation.
+	if !op.IsAUndefValue().IsNil() {
+		return parseUndef(op)
+	}
+
+	// Create and return a composite literal for aggregate constants.
+	//    {i32, i32} {i32 1, i32 2}  ; struct{_0 int32; _1 int32}{1, 2}
+	//    {i32, i32} zeroinitializer ; struct{_0 int32; _1 int32}{}
+	//    [3 x i8] c"hi\00"          ; [3]int8{104, 105, 0}
+	switch {
+	case !op.IsAConstantStruct().IsNil(), !op.IsAConstantArray().IsNil():
+		return parseConstAggregate(op)
+	case !op.IsAConstantDataArray().IsNil() && op.IsConstantString():
+		return parseConstString(op)
+	case !op.IsAConstantAggregateZero().IsNil():
+		return getZeroValue(op.Type())
+	}
+
+	// Create and return a string literal for pointers to string constants.
+	//    i8* getelementptr ([6 x i8]* @.str, i64 0, i64 0) ; "hello"
+	//
+	// Getelementptr instructions are translated by parseGEPInst instead, to
+	// make use of their result.
+	if lit, ok := getStringLit(op); ok && op.IsAInstruction().IsNil() {
+		return lit, nil
+	}
+
+	// Create and return a constant expression.
+	//    i32* getelementptr ([4 x i32]* @a, i64 0, i64 2) ; &a[2]
+	if !op.IsAConstantExpr().IsNil() {
+		return parseConstExpr(op)
+	}
+
+	// Replace global aliases with their aliasee.
+	//    @a = alias i32* @x ; &x
+	if !op.IsAGlobalAlias().IsNil() {
+		return parseOperand(getAliasee(op))
+	}
+
+	// Create and return the address of memory modeled as a Go variable.
+	//    %p = alloca i32 ; &_p
+	//    @x = global i32 0 ; &x
+	if isValueAlloca(op) {
+		x, err := getResult(op)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		return &ast.UnaryExpr{Op: token.AND, X: x}, nil
+	}
+	if isGlobalVar(op) {
+		return &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(globalName(op.GlobalParent(), op.Name()))}, nil
+	}
+
+	// Create and return a variable operand.
+	switch {
+	case !op.IsAGlobalValue().IsNil() && len(op.Name()) > 0:
+		//    @foo
+		if isIFunc(op) {
+			return nil, errutil.Newf("support for ifunc %q not yet implemented", op.Name())
+		}
+		return ast.NewIdent(globalName(op.GlobalParent(), op.Name())), nil
+	case !op.IsAInstruction().IsNil():
+		//    %foo = ...
+		//    %42 = ...
+		return getResult(op)
+	case !op.IsAArgument().IsNil():
+		//    %foo
+		//    %0
+		return getParamIdent(op)
+	}
+
+	debugValue(op)
+	return nil, errutil.New("support for LLVM IR operand not yet implemented")
+}
+
+// getParamIdent returns the Go identifier of the provided LLVM IR function
+// parameter. Unnamed parameters are identified by their ID, which is assigned
+// consecutively as by parseSig (e.g. "%0").
+func getParamIdent(param llvm.Value) (ast.Expr, error) {
+	fn := param.ParamParent()
+	if name := param.Name(); len(name) > 0 {
+		return ast.NewIdent(localName(fn, name)), nil
+	}
+	id := 0
+	for _, p := range fn.Params() {
+		if p == param {
+			return ast.NewIdent(localName(fn, strconv.Itoa(id))), nil
+		}
+		if len(p.Name()) == 0 {
+			id++
+		}
+	}
+	return nil, errutil.New("unable to locate parameter in parent function")
+}
+
+// getIntLit converts the provided LLVM IR integer constant into an equivalent Go
+// AST expression node (a basic literal or a boolean identifier).
+func getIntLit(c llvm.Value) (ast.Expr, error) {
+	switch width := c.Type().IntTypeWidth(); {
+	case width == 1:
+		//    i1 true
+		if c.ZExtValue() != 0 {
+			return newIdent("true"), nil
+		}
+		return newIdent("false"), nil
+	case width <= 64:
+		//    i8 -128
+		val := strconv.FormatInt(c.SExtValue(), 10)
+		return &ast.BasicLit{Kind: token.INT, Value: val}, nil
+	default:
+		return nil, errutil.Newf("support for integer constants of type i%d not yet implemented", width)
+	}
+}
+
+// getStringLit returns a Go string literal of the string constant pointed to by
+// the provided LLVM IR value, and a boolean indicating success. String
+// constants are null-terminated constant i8 arrays of global variables (e.g.
+// "hello\00"), which are pointed to either directly or through getelementptr
+// with zero indices.
+//
+//    @.str = private constant [6 x i8] c"hello\00"
+//    i8* getelementptr ([6 x i8]* @.str, i64 0, i64 0) ; "hello"
+//
+// Global variables with any other use (e.g. getelementptr with non-zero
+// indices) are declared by parseGlobals, and are not considered string
+// constants.
+func getStringLit(v llvm.Value) (*ast.BasicLit, bool) {
+	// Locate the global variable through getelementptr with zero indices.
+	for isGEP(v) {
+		if !isZeroGEP(v) {
+			return nil, false
+		}
+		v = v.Operand(0)
+	}
+	if v.IsAGlobalVariable().IsNil() || !v.IsGlobalConstant() {
+		return nil, false
+	}
+	for use := v.FirstUse(); !use.IsNil(); use = use.NextUse() {
+		if user := use.User(); !isGEP(user) || !isZeroGEP(user) {
+			return nil, false
+		}
+	}
+	init := v.Initializer()
+	if init.IsNil() || init.IsAConstantDataArray().IsNil() || !init.IsConstantString() {
+		return nil, false
+	}
+
+	// Only null-terminated strings of valid UTF-8 without embedded null
+	// characters are considered string-like; non-printable characters are
+	// escaped.
+	s := init.ConstGetAsString()
+	if !strings.HasSuffix(s, "\x00") {
+		return nil, false
+	}
+	s = s[:len(s)-1]
+	if strings.Contains(s, "\x00") || !utf8.ValidString(s) {
+		return nil, false
+	}
+	return &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(s)}, true
+}
+
+// isGEP returns true if the provided LLVM IR value is a getelementptr
+// instruction or constant expression, and false otherwise.
+func isGEP(v llvm.Value) bool {
+	return !v.IsAInstruction().IsNil() && v.InstructionOpcode() == llvm.GetElementPtr || !v.IsAConstantExpr().IsNil() && v.ConstOpcode() == llvm.GetElementPtr
+}
+
+// isZeroGEP returns true if all indices of the provided getelementptr
+// instruction or constant expression are zero, and false otherwise.
+//
+//    getelementptr ([6 x i8]* @.str, i64 0, i64 0)
+func isZeroGEP(v llvm.Value) bool {
+	for i := 1; i < v.OperandsCount(); i++ {
+		index := v.Operand(i)
+		if index.IsAConstantInt().IsNil() || index.ZExtValue() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRetInst converts the provided LLVM IR ret instruction into an equivalent
+// Go return statement. Aggregate values are returned as Go structs and arrays,
+// as declared by the function signature.
+//
+//    ret {i32, i32} {i32 1, i32 2} ; return struct{_0 int32; _1 int32}{1, 2}
+//
+// Syntax:
+//    ret void
+//    ret <type> <val>
+func parseRetInst(inst llvm.Value) (*ast.ReturnStmt, error) {
+	// Create and return a void return statement.
+	//    ret void
+	if inst.OperandsCount() == 0 {
+		return &ast.ReturnStmt{}, nil
+	}
+
+	// Create and return a return statement.
+	//    ret i32 %x
+	val, err := parseOperand(inst.Operand(0))
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	ret := &ast.ReturnStmt{
+		Results: []ast.Expr{val},
+	}
+	return ret, nil
+}
+
+// A definition captures the semantics of a PHI instruction's right-hand side,
+// i.e. it specifies a variable definition expression in relation to its source
+// basic block.
+type definition struct {
+	// Source basic block of the variable definition.
+	bb string
+	// Variable definition expression.
+	expr ast.Expr
+}
+
+// parsePHIInst converts the provided LLVM IR phi instruction into an equivalent
+// variable definition mapping.
+//
+// Syntax:
+//    %foo = phi i32 [ 42, %2 ], [ %bar, %3 ]
+func parsePHIInst(inst llvm.Value) (ident string, defs []*definition, err error) {
+	// Parse result.
+	result, err := getResult(inst)
+	if err != nil {
+		return "", nil, errutil.Err(err)
+	}
+	ident = result.(*ast.Ident).Name
+
+	// Parse incoming [value, predecessor] pairs.
+	for i := 0; i < inst.IncomingCount(); i++ {
+		// Parse variable definition expression.
+		expr, err := parseOperand(inst.IncomingValue(i))
+		if err != nil {
+			return "", nil, errutil.Err(err)
+		}
+
+		// Parse source basic block.
+		bbName, err := getBBName(inst.IncomingBlock(i).AsValue())
+		if err != nil {
+			return "", nil, errutil.Err(err)
+		}
+		def := &definition{bb: bbName, expr: expr}
+		defs = append(defs, def)
+	}
+	if len(defs) == 0 {
+		return "", nil, errutil.Newf("invalid PHI instruction %q; contains no incoming values", ident)
+	}
+
+	return ident, defs, nil
+}
+
+// getCmpPred returns a function which creates a Go expression equivalent of
+// the comparison predicate of the provided comparison instruction. The unsigned
+// return value specifies whether the operands must be treated as unsigned
+// integers.
+//
+// Syntax:
+//    <result> = icmp <pred> <type> <op1>, <op2>
+//    <result> = fcmp <pred> <type> <op1>, <op2>
+func getCmpPred(inst llvm.Value) (cmp cmpFunc, unsigned bool, err error) {
+	switch opcode := inst.InstructionOpcode(); opcode {
+	case llvm.ICmp:
+		return getIntCmpPred(inst.IntPredicate())
+	case llvm.FCmp:
+		return getFloatCmpPred(inst.FloatPredicate())
+	default:
+		return nil, false, errutil.Newf("invalid comparison instruction; expected icmp or fcmp, got %q", prettyOpcode(opcode))
+	}
+}
+
+// getIntCmpPred returns a function which creates a Go expression equivalent of
+// the provided integer comparison predicate. The unsigned return value
+// specifies whether the operands must be treated as unsigned integers.
+func getIntCmpPred(pred llvm.IntPredicate) (cmp cmpFunc, unsigned bool, err error) {
+	switch pred {
+	case llvm.IntEQ: // eq: equal
+		return newCmp(token.EQL), false, nil // ==
+	case llvm.IntNE: // ne: not equal
+		return newCmp(token.NEQ), false, nil // !=
+	case llvm.IntUGT: // ugt: unsigned greater than
+		return newCmp(token.GTR), true, nil // >
+	case llvm.IntUGE: // uge: unsigned greater or equal
+		return newCmp(token.GEQ), true, nil // >=
+	case llvm.IntULT: // ult: unsigned less than
+		return newCmp(token.LSS), true, nil // <
+	case llvm.IntULE: // ule: unsigned less or equal
+		return newCmp(token.LEQ), true, nil // <=
+	case llvm.IntSGT: // sgt: signed greater than
+		return newCmp(token.GTR), false, nil // >
+	case llvm.IntSGE: // sge: signed greater or equal
+		return newCmp(token.GEQ), false, nil // >=
+	case llvm.IntSLT: // slt: signed less than
+		return newCmp(token.LSS), false, nil // <
+	case llvm.IntSLE: // sle: signed less or equal
+		return newCmp(token.LEQ), false, nil // <=
+	default:
+		return nil, false, errutil.Newf("invalid integer comparison predicate %d", pred)
+	}
+}
+
+// getFloatCmpPred returns a function which creates a Go expression equivalent
+// of the provided floating-point comparison predicate. The unsigned return
+// value is always false.
+func getFloatCmpPred(pred llvm.FloatPredicate) (cmp cmpFunc, unsigned bool, err error) {
+	switch pred {
+	case llvm.FloatPredicateFalse: // false: no comparison, always returns false
+		return newConstCmp(false), false, nil // false
+	case llvm.FloatOEQ: // oeq: ordered and equal
+		return newCmp(token.EQL), false, nil // ==
+	case llvm.FloatOGT: // ogt: ordered and greater than
+		return newCmp(token.GTR), false, nil // >
+	case llvm.FloatOGE: // oge: ordered and greater than or equal
+		return newCmp(token.GEQ), false, nil // >=
+	case llvm.FloatOLT: // olt: ordered and less than
+		return newCmp(token.LSS), false, nil // <
+	case llvm.FloatOLE: // ole: ordered and less than or equal
+		return newCmp(token.LEQ), false, nil // <=
+	case llvm.FloatONE: // one: ordered and not equal
+		return cmpOne, false, nil // x < y || x > y
+	case llvm.FloatORD: // ord: ordered (no nans)
+		return cmpOrd, false, nil // x == x && y == y
+	case llvm.FloatUEQ: // ueq: unordered or equal
+		return cmpUeq, false, nil // !(x < y || x > y)
+	case llvm.FloatUGT: // ugt: unordered or greater than
+		return newNotCmp(token.LEQ), false, nil // !(x <= y)
+	case llvm.FloatUGE: // uge: unordered or greater than or equal
+		return newNotCmp(token.LSS), false, nil // !(x < y)
+	case llvm.FloatULT: // ult: unordered or less than
+		return newNotCmp(token.GEQ), false, nil // !(x >= y)
+	case llvm.FloatULE: // ule: unordered or less than or equal
+		return newNotCmp(token.GTR), false, nil // !(x > y)
+	case llvm.FloatUNE: // une: unordered or not equal
+		return newCmp(token.NEQ), false, nil // !=
+	case llvm.FloatUNO: // uno: unordered (either nans)
+		return cmpUno, false, nil // x != x || y != y
+	case llvm.FloatPredicateTrue: // true: no comparison, always returns true
+		return newConstCmp(true), false, nil // true
+	default:
+		return nil, false, errutil.Newf("invalid floating-point comparison predicate %d", pred)
+	}
+}
+
+// A cmpFunc creates a comparison expression of the operands x and y.
+type cmpFunc func(x, y ast.Expr) ast.Expr
+
+// newCmp returns a function which compares x and y using the given binary
+// operator.
+func newCmp(op token.Token) cmpFunc {
+	return func(x, y ast.Expr) ast.Expr {
+		return &ast.BinaryExpr{X: x, Op: op, Y: y}
+	}
+}
+
+// newNotCmp returns a function which compares x and y using the given binary
+// operator and negates the result. Comparisons involving a NaN are false, so
+// the negation is true for unordered operands.
+//
+//    !(x op y)
+func newNotCmp(op token.Token) cmpFunc {
+	return func(x, y ast.Expr) ast.Expr {
+		return &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: &ast.BinaryExpr{X: x, Op: op, Y: y}}}
+	}
+}
+
+// newConstCmp returns a function which ignores x and y and returns the given
+// boolean constant.
+func newConstCmp(val bool) cmpFunc {
+	return func(x, y ast.Expr) ast.Expr {
+		return newIdent(strconv.FormatBool(val))
+	}
+}
+
+// cmpOrd returns an expression which evaluates to true if neither x nor y is a
+// NaN, as a NaN is the only floating-point value which is not equal to itself.
+//
+//    x == x && y == y
+func cmpOrd(x, y ast.Expr) ast.Expr {
+	return &ast.BinaryExpr{
+		X:  &ast.BinaryExpr{X: x, Op: token.EQL, Y: x},
+		Op: token.LAND,
+		Y:  &ast.BinaryExpr{X: y, Op: token.EQL, Y: y},
+	}
+}
+
+// cmpUno returns an expression which evaluates to true if either x or y is a
+// NaN.
+//
+//    x != x || y != y
+func cmpUno(x, y ast.Expr) ast.Expr {
+	return &ast.BinaryExpr{
+		X:  &ast.BinaryExpr{X: x, Op: token.NEQ, Y: x},
+		Op: token.LOR,
+		Y:  &ast.BinaryExpr{X: y, Op: token.NEQ, Y: y},
+	}
+}
+
+// cmpOne returns an expression which evaluates to true if x and y are ordered
+// and not equal. Contrary to x != y, it evaluates to false if either x or y is
+// a NaN.
+//
+//    x < y || x > y
+func cmpOne(x, y ast.Expr) ast.Expr {
+	return &ast.BinaryExpr{
+		X:  &ast.BinaryExpr{X: x, Op: token.LSS, Y: y},
+		Op: token.LOR,
+		Y:  &ast.BinaryExpr{X: x, Op: token.GTR, Y: y},
+	}
+}
+
+// cmpUeq returns an expression which evaluates to true if x and y are unordered
+// or equal. Contrary to x == y, it evaluates to true if either x or y is a NaN.
+//
+//    !(x < y || x > y)
+func cmpUeq(x, y ast.Expr) ast.Expr {
+	return &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: cmpOne(x, y)}}
+}
+
+// getBrCond parses the provided conditional branch instruction and returns its
+// condition and the names of its true and false target basic blocks. Metadata
+// attachments (e.g. "!prof !0" branch weights) are not operands of the
+// instruction, and are therefore ignored.
+//
+// Syntax:
+//    br i1 <cond>, label <target_true>, label <target_false>
+func getBrCond(term llvm.Value) (cond ast.Expr, targetTrue, targetFalse string, err error) {
+	if isUncondBr(term) {
+		return nil, "", "", errutil.New("invalid branch instruction; expected conditional branch, got unconditional branch")
+	}
+
+	// The condition is the first operand of the branch instruction, and the
+	// true and false targets are its first and second successors respectively.
+	if term.OperandsCount() != 3 || term.SuccessorsCount() != 2 {
+		return nil, "", "", errutil.Newf("invalid conditional branch instruction; expected 3 operands and 2 successors, got %d and %d", term.OperandsCount(), term.SuccessorsCount())
+	}
+	cond, err = parseOperand(term.Operand(0))
+	if err != nil {
+		return nil, "", "", errutil.Err(err)
+	}
+	targetTrue, err = getBBName(term.Successor(0).AsValue())
+	if err != nil {
+		return nil, "", "", errutil.Err(err)
+	}
+	targetFalse, err = getBBName(term.Successor(1).AsValue())
+	if err != nil {
+		return nil, "", "", errutil.Err(err)
+	}
+	return cond, targetTrue, targetFalse, nil
+}
+
+// isUncondBr returns true if the provided terminator instruction is an
+// unconditional branch, and false otherwise. Invoke instructions whose unwind
+// destination is ignored are treated as unconditional branches to their normal
+// destination.
+//
+// Syntax:
+//    br label <dest>
+func isUncondBr(term llvm.Value) bool {
+	if ignoresUnwind(term) {
+		return true
+	}
+	return !term.IsNil() && term.InstructionOpcode() == llvm.Br && term.OperandsCount() == 1
+}
+
+// A switchCase represents a case of a switch instruction, i.e. it specifies the
+// target basic block of a case value.
+type switchCase struct {
+	// Case value.
+	val ast.Expr
+	// Target basic block.
+	target string
+}
+
+// getSwitchCases parses the provided switch instruction and returns its
+// condition, default target and cases.
+//
+// Syntax:
+//    switch <intty> <value>, label <defaultdest> [ <intty> <val>, label <dest> ... ]
+func getSwitchCases(term llvm.Value) (cond ast.Expr, targetDefault string, cases []*switchCase, err error) {
+	// The operands of the switch instruction are the condition and the default
+	// target, followed by a [value, target] pair for each case; and the
+	// successors are the default target followed by the target of each case.
+	if term.OperandsCount() < 2 || term.OperandsCount()%2 != 0 {
+		return nil, "", nil, errutil.Newf("invalid switch instruction; expected an even number of >= 2 operands, got %d", term.OperandsCount())
+	}
+
+	// Parse condition and default target.
+	cond, err = parseOperand(term.Operand(0))
+	if err != nil {
+		return nil, "", nil, errutil.Err(err)
+	}
+	targetDefault, err = getBBName(term.Successor(0).AsValue())
+	if err != nil {
+		return nil, "", nil, errutil.Err(err)
+	}
+
+	// Parse cases.
+	//    i32 1, label %3
+	for i := 1; i < term.SuccessorsCount(); i++ {
+		val := term.Operand(2 * i)
+		if val.IsAConstantInt().IsNil() {
+			return nil, "", nil, errutil.New("invalid switch case; expected integer constant")
+		}
+		lit, err := getIntLit(val)
+		if err != nil {
+			return nil, "", nil, errutil.Err(err)
+		}
+		target, err := getBBName(term.Successor(i).AsValue())
+		if err != nil {
+			return nil, "", nil, errutil.Err(err)
+		}
+		cases = append(cases, &switchCase{val: lit, target: target})
+	}
+	return cond, targetDefault, cases, nil
+}
+
+// getIdent converts the provided LLVM IR token into a Go identifier. Local
+// variables are named uniquely within the given LLVM IR function.
+func getIdent(fn llvm.Value, tok lltoken.Token) (ident ast.Expr, err error) {
+	switch tok.Kind {
+	case lltoken.KwTrue, lltoken.KwFalse, lltoken.GlobalVar:
+		return newIdent(tok.Val), nil
+	case lltoken.LocalVar, lltoken.LocalID:
+		// Local variable IDs (e.g. "%42") are translated to Go identifiers by
+		// adding the prefix of the LocalPrefix option (e.g. "_42").
+		return ast.NewIdent(localName(fn, tok.Val)), nil
+	default:
+		return nil, errutil.Newf("support for LLVM IR token kind %v not yet implemented", tok.Kind)
+	}
+}
+
+// getResult returns the result identifier of the provided assignment operation.
+//
+// Syntax:
+//    %foo = ...
+func getResult(inst llvm.Value) (result ast.Expr, err error) {
+	// Parse and validate tokens.
+	tokens, err := getTokens(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	if len(tokens) < 2 {
+		return nil, errutil.Newf("unable to locate result identifier; expected >= 2 tokens, got %d", len(tokens))
+	}
+	if eq := tokens[1]; eq.Kind != lltoken.Equal {
+		return nil, errutil.Newf("invalid assigment operation; expected '=' token, got %q", eq)
+	}
+
+	// Create and return the result identifier.
+	switch tok := tokens[0]; tok.Kind {
+	case lltoken.LocalVar, lltoken.LocalID:
+		return getIdent(parentFunc(inst), tok)
+	default:
+		return nil, errutil.Newf("support for LLVM IR token kind %v not yet implemented", tok.Kind)
+	}
+}
+
+// newIdent returns a new identifier based on the given string after replacing
+// any illegal characters with underscore and dropping any numeric suffixes
+// (e.g. "i.0" and "i.1" => "i"). Identifiers which collide with Go keywords or
+// start with a digit are given an underscore prefix (e.g. "range" => "_range").
+func newIdent(s string) *ast.Ident {
+	s = dropNumericSuffix(s)
+	f := func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r):
+			// valid rune in identifier.
+			return r
+		}
+		return '_'
+	}
+	s = strings.Map(f, s)
+	if token.Lookup(s).IsKeyword() || (len(s) > 0 && unicode.IsDigit(rune(s[0]))) {
+		s = "_" + s
+	}
+	return ast.NewIdent(s)
+}
+
+// dropNumericSuffix returns the given name without numeric suffixes (e.g.
+// "i.0" => "i" and "x.1.2" => "x").
+func dropNumericSuffix(s string) string {
+	for {
+		pos := strings.LastIndex(s, ".")
+		if pos <= 0 || !isDigits(s[pos+1:]) {
+			return s
+		}
+		s = s[:pos]
+	}
+}
+
+// isDigits returns true if the given string is a non-empty sequence of decimal
+// digits, and false otherwise.
+func isDigits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// prettyOpcode returns a string representation of the given LLVM IR instruction
+// opcode.
+func prettyOpcode(opcode llvm.Opcode) string {
+	m := map[llvm.Opcode]string{
+		llvm.Ret:         "Ret",
+		llvm.Br:          "Br",
+		llvm.Switch:      "Switch",
+		llvm.IndirectBr:  "IndirectBr",
+		llvm.Invoke:      "Invoke",
+		llvm.Resume:      "Resume",
+		llvm.Unreachable: "Unreachable",
+
+		// Standard Unary Operators
+		llvm.FNeg: "FNeg",
+
+		// Standard Binary Operators
+		llvm.Add:  "Add",
+		llvm.FAdd: "FAdd",
+		llvm.Sub:  "Sub",
+		llvm.FSub: "FSub",
+		llvm.Mul:  "Mul",
+		llvm.FMul: "FMul",
+		llvm.UDiv: "UDiv",
+		llvm.SDiv: "SDiv",
+		llvm.FDiv: "FDiv",
+		llvm.URem: "URem",
+		llvm.SRem: "SRem",
+		llvm.FRem: "FRem",
+
+		// Logical Operators
+		llvm.Shl:  "Shl",
+		llvm.LShr: "LShr",
+		llvm.AShr: "AShr",
+		llvm.And:  "And",
+		llvm.Or:   "Or",
+		llvm.Xor:  "Xor",
+
+		// Memory Operators
+		llvm.Alloca:        "Alloca",
+		llvm.Load:          "Load",
+		llvm.Store:         "Store",
+		llvm.GetElementPtr: "GetElementPtr",
+		llvm.AtomicCmpXchg: "AtomicCmpXchg",
+		llvm.AtomicRMW:     "AtomicRMW",
+		llvm.Fence:         "Fence",
+
+		// Cast Operators
+		llvm.Trunc:    "Trunc",
+		llvm.ZExt:     "ZExt",
+		llvm.SExt:     "SExt",
+		llvm.FPToUI:   "FPToUI",
+		llvm.FPToSI:   "FPToSI",
+		llvm.UIToFP:   "UIToFP",
+		llvm.SIToFP:   "SIToFP",
+		llvm.FPTrunc:  "FPTrunc",
+		llvm.FPExt:    "FPExt",
+		llvm.PtrToInt: "PtrToInt",
+		llvm.IntToPtr: "IntToPtr",
+		llvm.BitCast:  "BitCast",
+
+		// Other Operators
+		llvm.ICmp:           "ICmp",
+		llvm.FCmp:           "FCmp",
+		llvm.PHI:            "PHI",
+		llvm.Call:           "Call",
+		llvm.Select:         "Select",
+		llvm.VAArg:          "VAArg",
+		llvm.ExtractElement: "ExtractElement",
+		llvm.InsertElement:  "InsertElement",
+		llvm.ShuffleVector:  "ShuffleVector",
+		llvm.ExtractValue:   "ExtractValue",
+		llvm.InsertValue:    "InsertValue",
+		llvm.Freeze:         "Freeze",
+		llvm.LandingPad:     "LandingPad",
+	}
+
+	s, ok := m[opcode]
+	if !ok {
+		return fmt.Sprintf("<unknown opcode %d>", int(opcode))
+	}
+	return s
+}
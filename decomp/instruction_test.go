@@ -0,0 +1,212 @@
+package decomp
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	xprimitive "decomp.org/x/graphs/primitive"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+func TestGetFloatCmpPred(t *testing.T) {
+	golden := []struct {
+		pred llvm.FloatPredicate
+		want string
+	}{
+		{pred: llvm.FloatPredicateFalse, want: "false"},
+		{pred: llvm.FloatOEQ, want: "x == y"},
+		{pred: llvm.FloatOGT, want: "x > y"},
+		{pred: llvm.FloatOGE, want: "x >= y"},
+		{pred: llvm.FloatOLT, want: "x < y"},
+		{pred: llvm.FloatOLE, want: "x <= y"},
+		{pred: llvm.FloatONE, want: "x < y || x > y"},
+		{pred: llvm.FloatORD, want: "x == x && y == y"},
+		{pred: llvm.FloatUEQ, want: "!(x < y || x > y)"},
+		{pred: llvm.FloatUGT, want: "!(x <= y)"},
+		{pred: llvm.FloatUGE, want: "!(x < y)"},
+		{pred: llvm.FloatULT, want: "!(x >= y)"},
+		{pred: llvm.FloatULE, want: "!(x > y)"},
+		{pred: llvm.FloatUNE, want: "x != y"},
+		{pred: llvm.FloatUNO, want: "x != x || y != y"},
+		{pred: llvm.FloatPredicateTrue, want: "true"},
+	}
+	for _, g := range golden {
+		cmp, _, err := getFloatCmpPred(g.pred)
+		if err != nil {
+			t.Errorf("predicate %d: unexpected error: %v", g.pred, err)
+			continue
+		}
+		got := sprintNode(cmp(ast.NewIdent("x"), ast.NewIdent("y")))
+		if got != g.want {
+			t.Errorf("predicate %d: expression mismatch; expected %q, got %q", g.pred, g.want, got)
+		}
+	}
+}
+
+func TestNewIdent(t *testing.T) {
+	golden := []struct {
+		s    string
+		want string
+	}{
+		{s: "i", want: "i"},
+		{s: "i.0", want: "i"},
+		{s: "x.1.2", want: "x"},
+		{s: "my.var", want: "my_var"},
+		{s: ".str.1", want: "_str"},
+		{s: "foo$bar", want: "foo_bar"},
+		{s: "range", want: "_range"},
+		{s: "42", want: "_42"},
+		{s: "1.2", want: "_1"},
+	}
+	for i, g := range golden {
+		if got := newIdent(g.s).Name; got != g.want {
+			t.Errorf("i=%d: identifier of %q mismatch; expected %q, got %q", i, g.s, g.want, got)
+		}
+	}
+}
+
+func TestDropNumericSuffix(t *testing.T) {
+	golden := []struct {
+		s    string
+		want string
+	}{
+		{s: "i", want: "i"},
+		{s: "i.0", want: "i"},
+		{s: "x.1.2", want: "x"},
+		{s: "x.y.1", want: "x.y"},
+		{s: "x.1y", want: "x.1y"},
+		{s: "x.", want: "x."},
+		{s: ".1", want: ".1"},
+	}
+	for i, g := range golden {
+		if got := dropNumericSuffix(g.s); got != g.want {
+			t.Errorf("i=%d: name %q mismatch; expected %q, got %q", i, g.s, g.want, got)
+		}
+	}
+}
+
+// sprintNode returns the Go source code of the provided Go AST node.
+func TestParseCastInstBool(t *testing.T) {
+	golden := []struct {
+		inst string
+		want string
+	}{
+		{
+			inst: "%r = zext i1 %c to i32",
+			want: "r := func() int32 {\n\tif c {\n\t\treturn 1\n\t}\n\treturn 0\n}()",
+		},
+		{
+			inst: "%r = sext i1 %c to i8",
+			want: "r := func() int8 {\n\tif c {\n\t\treturn -1\n\t}\n\treturn 0\n}()",
+		},
+		{
+			inst: "%r = uitofp i1 %c to double",
+			want: "r := func() float64 {\n\tif c {\n\t\treturn 1\n\t}\n\treturn 0\n}()",
+		},
+		{
+			inst: "%r = sitofp i1 %c to float",
+			want: "r := func() float32 {\n\tif c {\n\t\treturn -1\n\t}\n\treturn 0\n}()",
+		},
+		{
+			inst: "%r = trunc i32 %x to i1",
+			want: "r := x&1 != 0",
+		},
+		{
+			inst: "%r = fptoui double %d to i1",
+			want: "r := d != 0",
+		},
+		{
+			inst: "%r = zext i8 %y to i32",
+			want: "r := int32(uint8(y))",
+		},
+	}
+	ctx := llvm.NewContext()
+	defer ctx.Dispose()
+	for _, g := range golden {
+		src := "define void @f(i1 %c, i8 %y, i32 %x, double %d) {\nentry:\n  " + g.inst + "\n  ret void\n}\n"
+		module, err := parseTestModule(ctx, src)
+		if err != nil {
+			t.Errorf("%q: unable to parse module; %v", g.inst, err)
+			continue
+		}
+		llFunc := module.NamedFunction("f")
+		newContext(llFunc, &Options{})
+		stmt, err := parseInst(llFunc.EntryBasicBlock().FirstInstruction())
+		releaseContext(llFunc)
+		module.Dispose()
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", g.inst, err)
+			continue
+		}
+		if got := sprintNode(stmt); got != g.want {
+			t.Errorf("%q: statement mismatch; expected %q, got %q", g.inst, g.want, got)
+		}
+	}
+}
+
+// parseTestModule parses the provided LLVM IR assembly into a module of the
+// given LLVM context.
+func parseTestModule(ctx llvm.Context, src string) (llvm.Module, error) {
+	dir, err := ioutil.TempDir("", "ll2go")
+	if err != nil {
+		return llvm.Module{}, err
+	}
+	defer os.RemoveAll(dir)
+	llPath := filepath.Join(dir, "test.ll")
+	if err := ioutil.WriteFile(llPath, []byte(src), 0644); err != nil {
+		return llvm.Module{}, err
+	}
+	return parseModule(ctx, llPath, dir)
+}
+
+func sprintNode(node ast.Node) string {
+	buf := new(bytes.Buffer)
+	if err := printer.Fprint(buf, token.NewFileSet(), node); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+func TestParseGEPInstNamedStruct(t *testing.T) {
+	const src = `
+%struct.S = type { i32, [4 x i32] }
+
+define i32* @f(%struct.S* %p, i64 %i) {
+entry:
+  %q = getelementptr %struct.S, %struct.S* %p, i64 0, i32 1, i64 2
+  %r = getelementptr inbounds %struct.S, %struct.S* %p, i64 %i, i32 0
+  store i32 1, i32* %r
+  ret i32* %q
+}
+`
+	got, err := decompileTest(src, Options{}, func(funcName string) []*xprimitive.Primitive {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The inbounds keyword has no Go equivalent and is ignored.
+	want := `package p
+
+type S struct {
+	_0	int32
+	_1	[4]int32
+}
+
+func f(p *S, i int64) *int32 {
+	q := &p._1[2]
+	r := &p[i]._0
+	*r = 1
+	return q
+}
+`
+	if got != want {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,405 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// foldLoopClauses folds the initialization and the increment of the induction
+// variable of pre-test loops into the init and post clauses of the for
+// statements. The induction variable is assigned right before the loop (e.g. a
+// lowered PHI instruction of the pre-header) and at the end of the loop body
+// (e.g. a lowered PHI instruction of the loop latch), and read by the loop
+// condition.
+//
+//    // from:
+//    i = 0
+//    for i < n {
+//       A
+//       _5 := i + 1
+//       i = _5
+//    }
+//
+//    // to:
+//    for i = 0; i < n; i++ {
+//       A
+//    }
+func foldLoopClauses(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			n.List = foldLoopStmts(body, n.List)
+		case *ast.CaseClause:
+			n.Body = foldLoopStmts(body, n.Body)
+		}
+		return true
+	})
+}
+
+// foldLoopStmts folds the init and post clauses of the for statements of the
+// provided statement list, which is part of the given function body.
+func foldLoopStmts(body *ast.BlockStmt, stmts []ast.Stmt) []ast.Stmt {
+	var list []ast.Stmt
+	for _, stmt := range stmts {
+		loop, label := stmt, (*ast.Ident)(nil)
+		if labeled, ok := stmt.(*ast.LabeledStmt); ok {
+			loop, label = labeled.Stmt, labeled.Label
+		}
+		forStmt, ok := loop.(*ast.ForStmt)
+		if ok && forStmt.Cond != nil && forStmt.Init == nil && forStmt.Post == nil && !hasLoopContinue(forStmt.Body, label) {
+			list = foldLoop(body, list, forStmt)
+		}
+		list = append(list, stmt)
+	}
+	return list
+}
+
+// foldLoop folds the init and post clauses of the provided for statement, if
+// its induction variable is assigned by the last statements of the preceding
+// statement list and of the loop body. The preceding statement list is returned
+// without the init statement.
+func foldLoop(body *ast.BlockStmt, prev []ast.Stmt, forStmt *ast.ForStmt) []ast.Stmt {
+	// Locate the increment at the end of the loop body.
+	_, post := findTrailingAssign(forStmt.Body.List, func(name string) bool {
+		return readsIdent(forStmt.Cond, name)
+	})
+	if post == nil {
+		return prev
+	}
+	name := post.Lhs[0].(*ast.Ident).Name
+
+	// Locate the initialization right before the loop.
+	i, init := findTrailingAssign(prev, func(s string) bool {
+		return s == name
+	})
+	if init == nil {
+		return prev
+	}
+
+	// Fold the clauses.
+	forStmt.Post = newPostStmt(body, forStmt.Body, post)
+	forStmt.Body.List = removeStmt(forStmt.Body.List, post)
+	forStmt.Init = init
+	return append(prev[:i:i], prev[i+1:]...)
+}
+
+// findTrailingAssign locates the last assignment of the provided statement
+// list to a variable accepted by f, among the trailing assignments of the
+// statement list, and returns its index. The assignment is only returned if it
+// may be moved past the assignments following it; i.e. they don't read or
+// write its variable and don't write the variables it reads.
+func findTrailingAssign(stmts []ast.Stmt, f func(name string) bool) (int, *ast.AssignStmt) {
+	for i := len(stmts) - 1; i >= 0; i-- {
+		assign, ok := stmts[i].(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return -1, nil
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok || assign.Tok != token.ASSIGN || !f(ident.Name) {
+			continue
+		}
+		reads := make(map[string]bool)
+		inspectIdents(assign.Rhs[0], func(ident *ast.Ident) {
+			reads[ident.Name] = true
+		})
+		for _, stmt := range stmts[i+1:] {
+			if mentionsIdent(stmt, map[string]bool{ident.Name: true}) || writesAny(stmt, reads) {
+				return -1, nil
+			}
+		}
+		return i, assign
+	}
+	return -1, nil
+}
+
+// newPostStmt returns the post statement of a for statement, based on the
+// assignment to the induction variable at the end of the loop body. A
+// temporary variable holding the incremented value (e.g. "_5 := i + 1") is
+// folded into the post statement if it isn't used elsewhere in the function
+// body, and is removed from the loop body.
+//
+//    i = i + 1 ; i++
+//    i = 1 + i ; i++
+//    i = i - 1 ; i--
+//    i = i + 2 ; i += 2
+func newPostStmt(body, loopBody *ast.BlockStmt, assign *ast.AssignStmt) ast.Stmt {
+	if t, ok := assign.Rhs[0].(*ast.Ident); ok && countIdents(body, t.Name) == 2 {
+		for i, stmt := range loopBody.List {
+			def, ok := stmt.(*ast.AssignStmt)
+			if !ok || def.Tok != token.DEFINE || len(def.Lhs) != 1 || len(def.Rhs) != 1 {
+				continue
+			}
+			if ident, ok := def.Lhs[0].(*ast.Ident); !ok || ident.Name != t.Name {
+				continue
+			}
+			// The value is computed after the following statements of the loop
+			// body, which must not write the variables it reads.
+			reads := make(map[string]bool)
+			inspectIdents(def.Rhs[0], func(ident *ast.Ident) {
+				reads[ident.Name] = true
+			})
+			safe := isPure(def.Rhs[0])
+			for _, stmt := range loopBody.List[i+1:] {
+				if stmt != assign && writesAny(stmt, reads) {
+					safe = false
+				}
+			}
+			if safe {
+				loopBody.List = append(loopBody.List[:i:i], loopBody.List[i+1:]...)
+				assign.Rhs[0] = def.Rhs[0]
+			}
+			break
+		}
+	}
+
+	if incDec := newIncDecStmt(assign); incDec != nil {
+		return incDec
+	}
+	x := assign.Lhs[0].(*ast.Ident)
+	expr, ok := assign.Rhs[0].(*ast.BinaryExpr)
+	if !ok {
+		return assign
+	}
+	if ident, ok := expr.X.(*ast.Ident); !ok || ident.Name != x.Name {
+		return assign
+	}
+	if tok, ok := assignOps[expr.Op]; ok {
+		return &ast.AssignStmt{Lhs: []ast.Expr{x}, Tok: tok, Rhs: []ast.Expr{expr.Y}}
+	}
+	return assign
+}
+
+// removeStmt returns the provided statement list without the given statement.
+func removeStmt(stmts []ast.Stmt, stmt ast.Stmt) []ast.Stmt {
+	for i, s := range stmts {
+		if s == stmt {
+			return append(stmts[:i:i], stmts[i+1:]...)
+		}
+	}
+	return stmts
+}
+
+// assignOps maps from binary operator to the corresponding assignment operator.
+var assignOps = map[token.Token]token.Token{
+	token.ADD: token.ADD_ASSIGN,
+	token.SUB: token.SUB_ASSIGN,
+	token.MUL: token.MUL_ASSIGN,
+	token.QUO: token.QUO_ASSIGN,
+	token.SHL: token.SHL_ASSIGN,
+	token.SHR: token.SHR_ASSIGN,
+}
+
+// hasLoopContinue returns true if the provided loop body contains a continue
+// statement which targets the loop (either unlabeled or using the given loop
+// label), or a goto statement, and false otherwise. The post statement would
+// be executed on such branches, contrary to the original control flow.
+func hasLoopContinue(loopBody *ast.BlockStmt, label *ast.Ident) bool {
+	found := false
+	ast.Walk(&continueFinder{label: label, found: &found}, loopBody)
+	return found
+}
+
+// continueFinder locates the continue statements which target a loop.
+type continueFinder struct {
+	// Label of the loop; or nil if unlabeled.
+	label *ast.Ident
+	// Nesting depth of loops within the loop body.
+	loops int
+	// found is set to true if any continue or goto statement was located.
+	found *bool
+}
+
+// Visit locates the continue statements of the node which target the loop.
+func (v *continueFinder) Visit(n ast.Node) ast.Visitor {
+	if *v.found {
+		return nil
+	}
+	switch n := n.(type) {
+	case *ast.FuncLit:
+		return nil
+	case *ast.ForStmt, *ast.RangeStmt:
+		return &continueFinder{label: v.label, loops: v.loops + 1, found: v.found}
+	case *ast.BranchStmt:
+		switch {
+		case n.Tok == token.GOTO:
+			*v.found = true
+		case n.Tok != token.CONTINUE:
+			// not a continue statement.
+		case n.Label == nil || len(n.Label.Name) == 0:
+			*v.found = v.loops == 0
+		case v.label != nil:
+			*v.found = n.Label.Name == v.label.Name
+		}
+	}
+	return v
+}
+
+// mentionsIdent returns true if the provided node refers to any of the given
+// variables, and false otherwise.
+func mentionsIdent(n ast.Node, names map[string]bool) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if names[n.Name] {
+				found = true
+			}
+		case *ast.SelectorExpr:
+			found = found || mentionsIdent(n.X, names)
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
+// writesAny returns true if the provided node may write any of the given
+// variables, either by assignment or through their address, and false
+// otherwise.
+func writesAny(n ast.Node, names map[string]bool) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok && names[ident.Name] {
+					found = true
+				}
+			}
+		case *ast.IncDecStmt:
+			if ident, ok := n.X.(*ast.Ident); ok && names[ident.Name] {
+				found = true
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && mentionsIdent(n.X, names) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// countIdents returns the number of occurrences of the given identifier within
+// the provided node.
+func countIdents(n ast.Node, name string) int {
+	count := 0
+	ast.Inspect(n, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// simplifyLoopConds rewrites the infinite loops of the function body which are
+// exited by a guard at the start of the loop body, into loops with the negated
+// guard condition as loop condition. Loop labels which are no longer used are
+// removed.
+//
+//    // from:
+//    for {
+//       if !cond {
+//          break
+//       }
+//       B
+//    }
+//
+//    // to:
+//    for cond {
+//       B
+//    }
+func simplifyLoopConds(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			simplifyLoopStmts(body, n.List)
+		case *ast.CaseClause:
+			simplifyLoopStmts(body, n.Body)
+		}
+		return true
+	})
+}
+
+// simplifyLoopStmts simplifies the conditions of the for statements of the
+// provided statement list, which is part of the given function body.
+func simplifyLoopStmts(body *ast.BlockStmt, stmts []ast.Stmt) {
+	for i, stmt := range stmts {
+		loop, label := stmt, (*ast.Ident)(nil)
+		if labeled, ok := stmt.(*ast.LabeledStmt); ok {
+			loop, label = labeled.Stmt, labeled.Label
+		}
+		forStmt, ok := loop.(*ast.ForStmt)
+		if !ok || !simplifyLoopCond(forStmt, label) {
+			continue
+		}
+		if label != nil && !hasLabelRef(body, label.Name) {
+			stmts[i] = forStmt
+		}
+	}
+}
+
+// simplifyLoopCond moves the guard at the start of the body of the provided
+// infinite loop, if any, into the loop condition. The guard is an if statement
+// which only contains a break statement targeting the loop (either unlabeled
+// or using the given loop label). The boolean return value indicates success.
+func simplifyLoopCond(forStmt *ast.ForStmt, label *ast.Ident) bool {
+	if forStmt.Init != nil || forStmt.Cond != nil || forStmt.Post != nil || len(forStmt.Body.List) == 0 {
+		return false
+	}
+	guard, ok := forStmt.Body.List[0].(*ast.IfStmt)
+	if !ok || guard.Init != nil || guard.Else != nil || len(guard.Body.List) != 1 {
+		return false
+	}
+	branch, ok := guard.Body.List[0].(*ast.BranchStmt)
+	if !ok || branch.Tok != token.BREAK {
+		return false
+	}
+	if branch.Label != nil && len(branch.Label.Name) > 0 && (label == nil || branch.Label.Name != label.Name) {
+		return false
+	}
+	forStmt.Cond = negateCond(guard.Cond)
+	forStmt.Body.List = forStmt.Body.List[1:]
+	return true
+}
+
+// negateCond returns the negation of the provided boolean expression.
+//
+//    !cond    ; cond
+//    cond     ; !cond
+//    x < y    ; !(x < y)
+func negateCond(cond ast.Expr) ast.Expr {
+	for {
+		paren, ok := cond.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		cond = paren.X
+	}
+	switch expr := cond.(type) {
+	case *ast.UnaryExpr:
+		if expr.Op == token.NOT {
+			if paren, ok := expr.X.(*ast.ParenExpr); ok {
+				return paren.X
+			}
+			return expr.X
+		}
+	case *ast.BinaryExpr:
+		return &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: expr}}
+	}
+	return &ast.UnaryExpr{Op: token.NOT, X: cond}
+}
+
+// hasLabelRef returns true if the provided function body contains a branch
+// statement which refers to the given label, and false otherwise.
+func hasLabelRef(body *ast.BlockStmt, name string) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if branch, ok := n.(*ast.BranchStmt); ok && branch.Label != nil && branch.Label.Name == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
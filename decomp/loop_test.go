@@ -0,0 +1,96 @@
+package decomp
+
+import "testing"
+
+func TestFoldLoopClauses(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Increment through a temporary variable.
+		{
+			src:  "func f(n int32) {\ni = 0\nfor i < n {\ng(i)\n_5 := i + 1\ni = _5\n}\n}",
+			want: "func f(n int32) {\n\tfor i = 0; i < n; i++ {\n\t\tg(i)\n\t}\n}",
+		},
+		// Decrement, and increments by other amounts.
+		{
+			src:  "func f(n int32) {\ni = n\nfor i > 0 {\ng(i)\ni = i - 1\n}\nj = 0\nfor j < n {\ng(j)\nj = j + 2\n}\n}",
+			want: "func f(n int32) {\n\tfor i = n; i > 0; i-- {\n\t\tg(i)\n\t}\n\tfor j = 0; j < n; j += 2 {\n\t\tg(j)\n\t}\n}",
+		},
+		// Initialization which isn't right before the loop.
+		{
+			src:  "func f(n int32) {\ni = 0\ng(i)\nfor i < n {\ni = i + 1\n}\n}",
+			want: "func f(n int32) {\n\ti = 0\n\tg(i)\n\tfor i < n {\n\t\ti = i + 1\n\t}\n}",
+		},
+		// Loop with a continue statement.
+		{
+			src:  "func f(n int32) {\ni = 0\nfor i < n {\nif i == 2 {\ncontinue\n}\ni = i + 1\n}\n}",
+			want: "func f(n int32) {\n\ti = 0\n\tfor i < n {\n\t\tif i == 2 {\n\t\t\tcontinue\n\t\t}\n\t\ti = i + 1\n\t}\n}",
+		},
+		// Increment followed by a read of the induction variable.
+		{
+			src:  "func f(n int32) {\ni = 0\nfor i < n {\ni = i + 1\ng(i)\n}\n}",
+			want: "func f(n int32) {\n\ti = 0\n\tfor i < n {\n\t\ti = i + 1\n\t\tg(i)\n\t}\n}",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		foldLoopClauses(f.Body)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+func TestSimplifyLoopConds(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Negated guard.
+		{
+			src:  "func f(i int32) {\nfor {\nif !(i < 10) {\nbreak\n}\ni++\n}\n}",
+			want: "func f(i int32) {\n\tfor i < 10 {\n\t\ti++\n\t}\n}",
+		},
+		// Guard which is negated.
+		{
+			src:  "func f(done bool) {\nfor {\nif done {\nbreak\n}\ng()\n}\n}",
+			want: "func f(done bool) {\n\tfor !done {\n\t\tg()\n\t}\n}",
+		},
+		// Labeled loop; the label is removed once unused.
+		{
+			src:  "func f(c bool) {\nloop0:\nfor {\nif !c {\nbreak loop0\n}\ng()\n}\n}",
+			want: "func f(c bool) {\n\tfor c {\n\t\tg()\n\t}\n}",
+		},
+		// Labeled loop; the label is kept if still used.
+		{
+			src:  "func f(c, d bool) {\nloop0:\nfor {\nif !c {\nbreak\n}\nfor {\nif d {\ncontinue loop0\n}\n}\n}\n}",
+			want: "func f(c, d bool) {\nloop0:\n\tfor c {\n\t\tfor {\n\t\t\tif d {\n\t\t\t\tcontinue loop0\n\t\t\t}\n\t\t}\n\t}\n}",
+		},
+		// Statements before the guard.
+		{
+			src:  "func f(c bool) {\nfor {\ng()\nif !c {\nbreak\n}\n}\n}",
+			want: "func f(c bool) {\n\tfor {\n\t\tg()\n\t\tif !c {\n\t\t\tbreak\n\t\t}\n\t}\n}",
+		},
+		// Guard with an else branch, and guard which breaks an outer loop.
+		{
+			src:  "func f(c bool) {\nloop0:\nfor {\nfor {\nif !c {\nbreak loop0\n}\n}\nif c {\nbreak\n} else {\ng()\n}\n}\n}",
+			want: "func f(c bool) {\nloop0:\n\tfor {\n\t\tfor {\n\t\t\tif !c {\n\t\t\t\tbreak loop0\n\t\t\t}\n\t\t}\n\t\tif c {\n\t\t\tbreak\n\t\t} else {\n\t\t\tg()\n\t\t}\n\t}\n}",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		simplifyLoopConds(f.Body)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// Pointer models, as specified by the Ptr option.
+const (
+	// PtrValue models the memory of each alloca instruction as a Go variable,
+	// whose address is taken on use.
+	//
+	//    var _p int32
+	//    _p = 42
+	//    _x := _p
+	PtrValue = "value"
+	// PtrPointer models the memory of each alloca instruction as a pointer
+	// created by new, which is dereferenced on use.
+	//
+	//    _p := new(int32)
+	//    *_p = 42
+	//    _x := *_p
+	PtrPointer = "pointer"
+)
+
+// isValueAlloca returns true if the provided LLVM IR value is an alloca
+// instruction whose memory is modeled as a Go variable, and false otherwise.
+func isValueAlloca(v llvm.Value) bool {
+	return !v.IsAAllocaInst().IsNil() && getOptions(v).Ptr == PtrValue
+}
+
+// parseMem converts the provided LLVM IR pointer operand into a Go AST
+// expression node of the memory it points to (a pointer dereference, or a
+// variable if the memory is modeled as a Go value).
+//
+// Examples:
+//    i32* %p    ; *_p
+//    i32* %p    ; _p (alloca in value mode)
+func parseMem(ptr llvm.Value) (ast.Expr, error) {
+	addr, err := parseOperand(ptr)
+	if err != nil {
+		return nil, err
+	}
+	return deref(addr), nil
+}
+
+// deref returns a dereference of the provided address, which simplifies to the
+// operand of address-of expressions.
+func deref(addr ast.Expr) ast.Expr {
+	if x, ok := addr.(*ast.UnaryExpr); ok && x.Op == token.AND {
+		return x.X
+	}
+	return &ast.StarExpr{X: addr}
+}
+
+// parseAllocaMem returns the Go AST node (a statement) which allocates the memory
+// of the provided LLVM IR alloca instruction, based on the pointer model.
+//
+//    var _p int32         // value
+//    var _p int32 = 0     // value, with Options.ZeroInit
+//    _p := new(int32)     // pointer
+func parseAllocaMem(inst llvm.Value, typ ast.Expr) (ast.Stmt, error) {
+	result, err := getResult(inst)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	if isValueAlloca(inst) {
+		ident, ok := result.(*ast.Ident)
+		if !ok {
+			return nil, errutil.Newf("invalid alloca result; expected *ast.Ident, got %T", result)
+		}
+		spec := &ast.ValueSpec{
+			Names: []*ast.Ident{ident},
+			Type:  typ,
+		}
+		if getOptions(inst).ZeroInit {
+			zero, err := getZeroValue(inst.Type().ElementType())
+			if err != nil {
+				return nil, errutil.Err(err)
+			}
+			spec.Values = []ast.Expr{zero}
+		}
+		decl := &ast.GenDecl{
+			Tok:   token.VAR,
+			Specs: []ast.Spec{spec},
+		}
+		return &ast.DeclStmt{Decl: decl}, nil
+	}
+	lhs := []ast.Expr{result}
+	rhs := []ast.Expr{&ast.CallExpr{Fun: newIdent("new"), Args: []ast.Expr{typ}}}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}, nil
+}
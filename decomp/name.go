@@ -0,0 +1,188 @@
+package decomp
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// A namer tracks the Go identifiers of the local variables of a function, or of
+// the global variables and functions of a module, to guarantee that distinct
+// LLVM IR names are translated into distinct Go identifiers (e.g. "%my.var" and
+// "%my_var", or "%i.0" and "%i.1").
+type namer struct {
+	// names maps from LLVM IR name to Go identifier.
+	names map[string]string
+	// used tracks the Go identifiers in use.
+	used map[string]bool
+}
+
+// newNamer returns a new namer without any Go identifiers in use.
+func newNamer() *namer {
+	return &namer{names: make(map[string]string), used: make(map[string]bool)}
+}
+
+// name returns the Go identifier of the provided LLVM IR name, which is based on
+// the given identifier. Collisions are resolved by adding a numeric suffix
+// (e.g. "i_1").
+func (n *namer) name(name, ident string) string {
+	if s, ok := n.names[name]; ok {
+		return s
+	}
+	s := ident
+	for i := 1; n.used[s]; i++ {
+		s = fmt.Sprintf("%s_%d", ident, i)
+	}
+	n.names[name] = s
+	n.used[s] = true
+	return s
+}
+
+// localName returns the Go identifier of the provided local variable name of
+// the given LLVM IR function. Numeric suffixes are dropped (e.g. "i.0" =>
+// "i"), and collisions are resolved by adding a numeric suffix (e.g. "i.1" =>
+// "i_1" and "my_var" => "my_var_1"). Local variable IDs (e.g. "42") are given
+// the prefix of the LocalPrefix option (e.g. "_42").
+func localName(fn llvm.Value, name string) string {
+	ident := newIdent(name).Name
+	if prefix := getOptions(fn).LocalPrefix; len(prefix) > 0 && isDigits(name) {
+		ident = prefix + name
+	}
+	if fn.IsNil() {
+		return ident
+	}
+	ctx := getContext(fn)
+	if ctx == nil {
+		return ident
+	}
+	return ctx.names.name(name, ident)
+}
+
+var (
+	// globalNames maps from LLVM IR module to the Go identifiers of its global
+	// variables and functions.
+	globalNames = make(map[llvm.Module]*namer)
+	// globalNamesMu protects globalNames, as modules may be decompiled in
+	// parallel.
+	globalNamesMu sync.Mutex
+)
+
+// globalName returns the Go identifier of the provided global variable or
+// function name of the given LLVM IR module. Numeric suffixes are dropped (e.g.
+// ".str.1" => "_str"), and collisions are resolved by adding a numeric suffix
+// in the order of definition within the module, functions first (e.g. ".str" =>
+// "_str" and ".str.1" => "_str_1").
+func globalName(module llvm.Module, name string) string {
+	globalNamesMu.Lock()
+	defer globalNamesMu.Unlock()
+	n, ok := globalNames[module]
+	if !ok {
+		n = newNamer()
+		for f := module.FirstFunction(); !f.IsNil(); f = llvm.NextFunction(f) {
+			n.name(f.Name(), newIdent(f.Name()).Name)
+		}
+		for g := module.FirstGlobal(); !g.IsNil(); g = llvm.NextGlobal(g) {
+			if len(g.Name()) > 0 {
+				n.name(g.Name(), newIdent(g.Name()).Name)
+			}
+		}
+		globalNames[module] = n
+	}
+	return n.name(name, newIdent(name).Name)
+}
+
+// releaseGlobalNames releases the Go identifiers of the global variables and
+// functions of the provided LLVM IR module.
+func releaseGlobalNames(module llvm.Module) {
+	globalNamesMu.Lock()
+	defer globalNamesMu.Unlock()
+	delete(globalNames, module)
+}
+
+// varArgsName returns the Go identifier of the variadic parameter of the
+// provided LLVM IR function (e.g. "args"), which is distinct from the Go
+// identifiers of its local variables.
+func varArgsName(fn llvm.Value) string {
+	const ident = "args"
+	ctx := getContext(fn)
+	if ctx == nil {
+		return ident
+	}
+	if len(ctx.varArgs) > 0 {
+		return ctx.varArgs
+	}
+	n := ctx.names
+	s := ident
+	for i := 1; n.used[s]; i++ {
+		s = fmt.Sprintf("%s_%d", ident, i)
+	}
+	n.used[s] = true
+	ctx.varArgs = s
+	return s
+}
+
+// tempName returns a new Go identifier for a temporary copy of the provided
+// identifier (e.g. "x_tmp") within the given LLVM IR function, which is
+// distinct from the Go identifiers of its local variables.
+func tempName(fn llvm.Value, ident string) string {
+	base := ident + "_tmp"
+	ctx := getContext(fn)
+	if ctx == nil {
+		return base
+	}
+	n := ctx.names
+	s := base
+	for i := 1; n.used[s]; i++ {
+		s = fmt.Sprintf("%s_%d", base, i)
+	}
+	n.used[s] = true
+	return s
+}
+
+// isIdentPrefix returns true if the provided string is a valid prefix of Go
+// identifiers; i.e. it starts with a letter or an underscore, followed by
+// letters, digits and underscores.
+func isIdentPrefix(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r), r == '_':
+		case unicode.IsDigit(r) && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizePkgName returns a valid Go package name based on the provided name,
+// by dropping characters which are invalid in identifiers (e.g. "my-pkg" =>
+// "mypkg") and adding an underscore prefix to names which start with a digit or
+// collide with Go keywords (e.g. "123-foo" => "_123foo"). An error is returned
+// if no valid package name remains.
+func sanitizePkgName(name string) (string, error) {
+	f := func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), r == '_':
+			// valid rune in identifier.
+			return r
+		}
+		// drop invalid rune.
+		return -1
+	}
+	s := strings.Map(f, name)
+	if token.Lookup(s).IsKeyword() || (len(s) > 0 && unicode.IsDigit(rune(s[0]))) {
+		s = "_" + s
+	}
+	if len(s) == 0 || s == "_" {
+		return "", errutil.Newf("invalid package name %q; expected at least one letter or digit", name)
+	}
+	return s, nil
+}
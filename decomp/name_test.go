@@ -0,0 +1,82 @@
+package decomp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamer(t *testing.T) {
+	golden := []struct {
+		name  string
+		ident string
+		want  string
+	}{
+		{name: "i.0", ident: "i", want: "i"},
+		{name: "i.1", ident: "i", want: "i_1"},
+		{name: "i.0", ident: "i", want: "i"},
+		{name: "my.var", ident: "my_var", want: "my_var"},
+		{name: "my_var", ident: "my_var", want: "my_var_1"},
+		{name: "i_1", ident: "i_1", want: "i_1_1"},
+		{name: "i.1", ident: "i", want: "i_1"},
+	}
+	n := newNamer()
+	for i, g := range golden {
+		got := n.name(g.name, g.ident)
+		if got != g.want {
+			t.Errorf("i=%d: name %q mismatch; expected %q, got %q", i, g.name, g.want, got)
+		}
+	}
+}
+
+func TestSanitizePkgName(t *testing.T) {
+	golden := []struct {
+		name string
+		want string
+		err  string
+	}{
+		{name: "foo", want: "foo"},
+		{name: "my-pkg", want: "mypkg"},
+		{name: "my_pkg.v2", want: "my_pkgv2"},
+		{name: "123-foo", want: "_123foo"},
+		{name: "range", want: "_range"},
+		{name: "--", err: `invalid package name "--"; expected at least one letter or digit`},
+		{name: "_", err: `invalid package name "_"; expected at least one letter or digit`},
+	}
+	for i, g := range golden {
+		got, err := sanitizePkgName(g.name)
+		if err != nil {
+			if len(g.err) == 0 || !strings.HasSuffix(err.Error(), g.err) {
+				t.Errorf("i=%d: error mismatch; expected %q, got %q", i, g.err, err)
+			}
+			continue
+		}
+		if len(g.err) > 0 {
+			t.Errorf("i=%d: expected error %q, got nil", i, g.err)
+			continue
+		}
+		if got != g.want {
+			t.Errorf("i=%d: package name %q mismatch; expected %q, got %q", i, g.name, g.want, got)
+		}
+	}
+}
+
+func TestIsIdentPrefix(t *testing.T) {
+	golden := []struct {
+		s    string
+		want bool
+	}{
+		{s: "_", want: true},
+		{s: "v", want: true},
+		{s: "_t", want: true},
+		{s: "v2_", want: true},
+		{s: "", want: false},
+		{s: "2v", want: false},
+		{s: "v.", want: false},
+		{s: "a-b", want: false},
+	}
+	for i, g := range golden {
+		if got := isIdentPrefix(g.s); got != g.want {
+			t.Errorf("i=%d: isIdentPrefix(%q) mismatch; expected %v, got %v", i, g.s, g.want, got)
+		}
+	}
+}
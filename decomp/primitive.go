@@ -0,0 +1,1542 @@
+package decomp
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"decomp.org/x/graphs"
+	xprimitive "decomp.org/x/graphs/primitive"
+	"github.com/mewfork/dot"
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// primitive represents a control flow primitive, such as a 2-way conditional, a
+// pre-test loop or a single statement or a list of statements. Each primitive
+// conceptually represents a basic block and may be treated as an instruction or
+// a statement of other basic blocks.
+type primitive struct {
+	// The control flow primitive is conceptually a basic block, and as such
+	// requires a basic block name, statements and a terminator instruction.
+	blockData
+}
+
+// restructure attempts to create a structured control flow for a function based
+// on the provided control flow graph (which contains one node per basic block)
+// and the function's basic blocks. It does so by repeatedly locating and
+// merging structured subgraphs into single nodes until the entire graph is
+// reduced into a single node or no structured subgraphs may be located.
+//
+// If the graph cannot be reduced into a single node and the Goto option is set,
+// the remaining nodes are emitted as labeled statements and their terminators
+// are converted into goto statements. The entry specifies the name of the entry
+// basic block of the function.
+func restructure(graph *dot.Graph, bbs map[string]BasicBlock, hprims []*xprimitive.Primitive, entry string, opts *Options) (*ast.BlockStmt, error) {
+	// aliases maps from the name of each merged node to the name of the
+	// primitive it was merged into.
+	aliases := make(map[string]string)
+	for _, hprim := range mergeListChains(hprims) {
+		subName := hprim.Prim // identified primitive; e.g. "if", "if_else"
+		m := hprim.Nodes      // node mapping
+		newName := hprim.Node // new node name
+
+		// Create a control flow primitive based on the identified subgraph.
+		primBBs := make(map[string]BasicBlock)
+		for _, gname := range m {
+			bb, ok := bbs[gname]
+			if !ok {
+				return nil, errutil.Newf("unable to locate basic block %q", gname)
+			}
+			primBBs[gname] = bb
+			delete(bbs, gname)
+			aliases[gname] = newName
+		}
+		prim, err := createPrim(subName, m, primBBs, newName)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		Debug.Println("located primitive:")
+		printBB(prim)
+		bbs[prim.Name()] = prim
+	}
+
+	// Reduce the infinite loops which remain after structuring, as they have no
+	// exit node and are therefore not identified as loop primitives.
+	err := reduceInfLoops(bbs, aliases, entry)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	if len(bbs) > 1 {
+		if !opts.Goto {
+			var names []string
+			for name := range bbs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, errutil.Newf("unable to structure control flow graph; %d nodes remain (%s); set the Goto option to emit goto statements", len(bbs), strings.Join(names, ", "))
+		}
+		block, err := createGotoBlock(bbs, aliases, entry)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		labelLoops(block)
+		return block, nil
+	}
+
+	for _, bb := range bbs {
+		if !bb.Term().IsNil() {
+			debugValue(bb.Term())
+			return nil, errutil.Newf("invalid terminator instruction of last basic block in function; expected nil since return statements are already handled")
+		}
+		block := &ast.BlockStmt{
+			List: bb.Stmts(),
+		}
+		labelLoops(block)
+		return block, nil
+	}
+	return nil, errutil.New("unable to locate basic block")
+}
+
+// resolveAlias returns the name of the node which contains the given basic
+// block, based on aliases which maps from the name of each merged node to the
+// name of the primitive it was merged into.
+func resolveAlias(aliases map[string]string, name string) string {
+	for {
+		alias, ok := aliases[name]
+		if !ok {
+			return name
+		}
+		name = alias
+	}
+}
+
+// reduceInfLoops repeatedly reduces the infinite loops of the remaining nodes,
+// and merges each node into its predecessor if it is the only successor of its
+// only predecessor. The reduced infinite loops have no terminator, thus
+// satisfying the invariant of the last node of a function.
+//
+// Infinite loop:
+//
+//    A:
+//       br label %A
+//
+//    // to:
+//    for {
+//       A
+//    }
+//
+// Infinite loop with a return exit:
+//
+//    A:
+//       br i1 %cond, label %B, label %A
+//    B:
+//       ret i32 %x
+//
+//    // to:
+//    for {
+//       A
+//       if cond {
+//          B
+//       }
+//    }
+func reduceInfLoops(bbs map[string]BasicBlock, aliases map[string]string, entry string) error {
+	entry = resolveAlias(aliases, entry)
+	for changed := true; changed; {
+		changed = false
+
+		// Locate the successors and predecessors of each node.
+		succs := make(map[string][]string)
+		preds := make(map[string][]string)
+		var names []string
+		for name, bb := range bbs {
+			names = append(names, name)
+			term := bb.Term()
+			if term.IsNil() {
+				continue
+			}
+			for _, succ := range getSuccessors(term) {
+				target, err := getBBName(succ.AsValue())
+				if err != nil {
+					return errutil.Err(err)
+				}
+				target = resolveAlias(aliases, target)
+				succs[name] = append(succs[name], target)
+				preds[target] = append(preds[target], name)
+			}
+		}
+		sort.Strings(names)
+
+		// onlyPred reports whether name is the only predecessor of target.
+		onlyPred := func(target, name string) bool {
+			ps := preds[target]
+			return target != entry && target != name && len(ps) == 1 && ps[0] == name
+		}
+
+		for _, name := range names {
+			bb := bbs[name]
+			term := bb.Term()
+			switch {
+			case isUncondBr(term) && succs[name][0] == name:
+				// Infinite loop.
+				//    for { A }
+				bbs[name] = newInfLoopPrim(name, bb.Stmts())
+				changed = true
+			case isUncondBr(term) && onlyPred(succs[name][0], name):
+				// List.
+				//    A
+				//    B
+				target := succs[name][0]
+				bbTarget := bbs[target]
+				stmts := append(bb.Stmts(), bbTarget.Stmts()...)
+				bbs[name] = &primitive{
+					blockData: blockData{name: name, stmts: stmts, term: bbTarget.Term()},
+				}
+				delete(bbs, target)
+				aliases[target] = name
+				changed = true
+			case !term.IsNil() && term.InstructionOpcode() == llvm.Br && !isUncondBr(term):
+				// Infinite loop with a return exit.
+				//    for { A; if cond { B } }
+				cond, targetTrue, targetFalse, err := getBrCond(term)
+				if err != nil {
+					return errutil.Err(err)
+				}
+				targetTrue, targetFalse = resolveAlias(aliases, targetTrue), resolveAlias(aliases, targetFalse)
+				var exit string
+				switch name {
+				case targetFalse:
+					exit = targetTrue
+				case targetTrue:
+					exit = targetFalse
+					cond = &ast.UnaryExpr{Op: token.NOT, X: cond}
+				default:
+					continue
+				}
+				bbExit, ok := bbs[exit]
+				if !ok || !bbExit.Term().IsNil() || !onlyPred(exit, name) {
+					continue
+				}
+				cond = expandCond(bb, cond)
+				ifStmt := &ast.IfStmt{
+					Cond: cond,
+					Body: &ast.BlockStmt{List: bbExit.Stmts()},
+				}
+				stmts := append(bb.Stmts(), ifStmt)
+				bbs[name] = newInfLoopPrim(name, stmts)
+				delete(bbs, exit)
+				aliases[exit] = name
+				changed = true
+			}
+			if changed {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// newInfLoopPrim returns a new primitive of an infinite loop with the given
+// body. The new primitive has no terminator, as the loop is only exited by
+// return statements within its body.
+//
+//    for {
+//       body
+//    }
+func newInfLoopPrim(name string, body []ast.Stmt) *primitive {
+	forStmt := &ast.ForStmt{
+		Body: &ast.BlockStmt{List: body},
+	}
+	stmts := []ast.Stmt{newLoop(forStmt, name, "")}
+	return &primitive{
+		blockData: blockData{name: name, stmts: stmts},
+	}
+}
+
+// createPrim creates a control flow primitive based on the identified subgraph
+// and its node pair mapping and basic blocks. The new control flow primitive
+// conceptually forms a new basic block with the specified name.
+func createPrim(subName string, m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	switch subName {
+	case "if":
+		return createIfPrim(m, bbs, newName)
+	case "if_else":
+		return createIfElsePrim(m, bbs, newName)
+	case "if_return":
+		return createIfReturnPrim(m, bbs, newName)
+	case "if_and":
+		return createShortCircuitPrim(m, bbs, newName, token.LAND)
+	case "if_or":
+		return createShortCircuitPrim(m, bbs, newName, token.LOR)
+	case "list":
+		return createListPrim(m, bbs, newName)
+	case "post_loop":
+		return createPostLoopPrim(m, bbs, newName)
+	case "pre_loop":
+		return createPreLoopPrim(m, bbs, newName)
+	case "pre_loop_continue":
+		return createPreLoopContinuePrim(m, bbs, newName)
+	case "nested_pre_loop_break":
+		return createNestedPreLoopBreakPrim(m, bbs, newName)
+	case "switch":
+		return createSwitchPrim(m, bbs, newName)
+	default:
+		return nil, errutil.Newf("control flow primitive of subgraph %q not yet supported", subName)
+	}
+}
+
+// createListPrim creates a list primitive containing a slice of Go statements
+// based on the identified subgraph, its node pair mapping and its basic blocks.
+// The new control flow primitive conceptually represents a basic block with the
+// given name.
+//
+// Contents of "list.dot":
+//
+//    digraph list {
+//       A [label="entry"]
+//       B [label="exit"]
+//       A->B
+//    }
+//
+// Chains of list primitives merged by mergeListChains map the additional sub
+// nodes "C", "D", etc, in the order of the chain.
+func createListPrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	if _, ok := m["A"]; !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	if _, ok := m["B"]; !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+
+	// The terminator of each basic block is an unconditional branch to the next
+	// basic block of the chain, which is implied by the order of the
+	// statements; thus getBrCond is not used.
+
+	// Create and return new primitive.
+	//
+	//    A
+	//    B
+	var stmts []ast.Stmt
+	var bb BasicBlock
+	for _, name := range listNodes(m) {
+		var ok bool
+		bb, ok = bbs[name]
+		if !ok {
+			return nil, errutil.Newf("unable to locate basic block %q", name)
+		}
+		stmts = append(stmts, bb.Stmts()...)
+	}
+	prim := &primitive{
+		blockData: blockData{name: newName, stmts: stmts, term: bb.Term()},
+	}
+	return prim, nil
+}
+
+// listNodes returns the graph node names of the provided node pair mapping of a
+// list primitive, in the order of the chain (i.e. sub nodes "A", "B", "C",
+// etc).
+func listNodes(m map[string]string) []string {
+	var names []string
+	for sname := 'A'; sname <= 'Z'; sname++ {
+		name, ok := m[string(sname)]
+		if !ok {
+			break
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// mergeListChains merges the consecutive list primitives of the structuring
+// results which form a straight-line chain of nodes (i.e. where each list
+// primitive contains the node of the previous one) into a single list
+// primitive, which is created in one step rather than one node pair at the
+// time. Chains are limited to the 26 sub nodes "A" through "Z".
+//
+//    // from:
+//    list(A: "0", B: "1") -> "list0"
+//    list(A: "list0", B: "2") -> "list1"
+//
+//    // to:
+//    list(A: "0", B: "1", C: "2") -> "list1"
+func mergeListChains(hprims []*xprimitive.Primitive) []*xprimitive.Primitive {
+	var merged []*xprimitive.Primitive
+	for _, hprim := range hprims {
+		if n := len(merged); n > 0 && hprim.Prim == "list" && merged[n-1].Prim == "list" {
+			chain := merged[n-1]
+			names := listNodes(chain.Nodes)
+			a, b := hprim.Nodes["A"], hprim.Nodes["B"]
+			ok := len(names) < 'Z'-'A'+1 && len(hprim.Nodes) == 2
+			switch {
+			case ok && a == chain.Node:
+				//    chain -> B
+				names = append(names, b)
+			case ok && b == chain.Node:
+				//    A -> chain
+				names = append([]string{a}, names...)
+			default:
+				ok = false
+			}
+			if ok {
+				m := make(map[string]string)
+				for i, name := range names {
+					m[string('A'+rune(i))] = name
+				}
+				merged[n-1] = &xprimitive.Primitive{Prim: "list", Nodes: m, Node: hprim.Node}
+				continue
+			}
+		}
+		merged = append(merged, hprim)
+	}
+	return merged
+}
+
+// createIfPrim creates an if-statement primitive based on the identified
+// subgraph, its node pair mapping and its basic blocks. The new control flow
+// primitive conceptually represents a basic block with the given name.
+//
+// Contents of "if.dot":
+//
+//    digraph if {
+//       A [label="entry"]
+//       B
+//       C [label="exit"]
+//       A->B [label="true"]
+//       A->C [label="false"]
+//       B->C
+//    }
+func createIfPrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	nameC, ok := m["C"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "C"`)
+	}
+	bbCond, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+	bbBody, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+	bbExit, ok := bbs[nameC]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameC)
+	}
+
+	// Create and return new primitive.
+	//
+	//    A
+	//    if A_cond {
+	//       B
+	//    }
+	//    C
+
+	// Create if-statement.
+	cond, _, _, err := getBrCond(bbCond.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	cond = expandCond(bbCond, cond)
+	ifStmt := &ast.IfStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{List: bbBody.Stmts()},
+	}
+
+	// Create primitive.
+	stmts := append(bbCond.Stmts(), ifStmt)
+	stmts = append(stmts, bbExit.Stmts()...)
+	prim := &primitive{
+		blockData: blockData{name: newName, stmts: stmts, term: bbExit.Term()},
+	}
+	return prim, nil
+}
+
+// createIfReturnPrim creates an if-statement primitive with a returning body
+// based on the identified subgraph, its node pair mapping and its basic blocks.
+// The new control flow primitive conceptually represents a basic block with the
+// given name.
+//
+// Contents of "if_return.dot":
+//
+//    digraph if_return {
+//       A [label="entry"]
+//       B
+//       C [label="exit"]
+//       A->B [label="true"]
+//       A->C [label="false"]
+//    }
+func createIfReturnPrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	nameC, ok := m["C"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "C"`)
+	}
+	bbCond, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+	bbBody, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+	bbExit, ok := bbs[nameC]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameC)
+	}
+
+	// Verify that the body never falls through to the exit, as the graph shape
+	// of if_return is indistinguishable from an if-statement whose body has no
+	// successors for other reasons. The body either returns, panics (e.g.
+	// unreachable) or loops forever.
+	//
+	//    if A_cond {
+	//       return
+	//    }
+	//
+	// and not
+	//
+	//    if A_cond {
+	//       f()
+	//    }
+	if !bbBody.Term().IsNil() {
+		return nil, errutil.Newf("invalid body of if_return primitive; expected return terminator in basic block %q", nameB)
+	}
+	body := bbBody.Stmts()
+	if len(body) == 0 {
+		return nil, errutil.Newf("invalid body of if_return primitive; basic block %q contains no statements", nameB)
+	}
+	if !isTerminating(body[len(body)-1]) {
+		return nil, errutil.Newf("invalid body of if_return primitive; expected terminating statement (e.g. return or panic) at end of basic block %q, got %T", nameB, body[len(body)-1])
+	}
+
+	// Create and return new primitive.
+	//
+	//    A
+	//    if A_cond {
+	//       B
+	//    }
+	//    C
+
+	// Create if-statement. The condition is negated if the body is located at
+	// the false branch.
+	cond, targetTrue, targetFalse, err := getBrCond(bbCond.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	cond = expandCond(bbCond, cond)
+	switch nameB {
+	case targetTrue:
+	case targetFalse:
+		cond = &ast.UnaryExpr{Op: token.NOT, X: cond}
+	default:
+		return nil, errutil.Newf("invalid branch targets; expected %q or %q, got %q", targetTrue, targetFalse, nameB)
+	}
+	ifStmt := &ast.IfStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{List: body},
+	}
+
+	// Create primitive.
+	stmts := append(bbCond.Stmts(), ifStmt)
+	stmts = append(stmts, bbExit.Stmts()...)
+	prim := &primitive{
+		blockData: blockData{name: newName, stmts: stmts, term: bbExit.Term()},
+	}
+	return prim, nil
+}
+
+// isTerminating returns true if control never flows past the provided statement,
+// as it returns, panics or loops forever, and false otherwise.
+//
+//    return x
+//    panic("unreachable")
+//    for {
+//       f()
+//    }
+func isTerminating(stmt ast.Stmt) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.ExprStmt:
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		ident, ok := call.Fun.(*ast.Ident)
+		return ok && ident.Name == "panic"
+	case *ast.BlockStmt:
+		return len(stmt.List) > 0 && isTerminating(stmt.List[len(stmt.List)-1])
+	case *ast.IfStmt:
+		return stmt.Else != nil && isTerminating(stmt.Body) && isTerminating(stmt.Else)
+	case *ast.LabeledStmt:
+		return isTerminating(stmt.Stmt)
+	case *ast.ForStmt:
+		// Infinite loops are terminating unless exited by a break statement.
+		// Labeled break and goto statements are conservatively assumed to exit
+		// the loop, as their labels are resolved after structuring.
+		if stmt.Cond != nil || hasUnlabeledBreak(stmt.Body.List) {
+			return false
+		}
+		exits := false
+		ast.Inspect(stmt.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.BranchStmt:
+				if n.Tok == token.GOTO || n.Tok == token.BREAK && n.Label != nil {
+					exits = true
+				}
+			}
+			return !exits
+		})
+		return !exits
+	}
+	return false
+}
+
+// createShortCircuitPrim creates an if-statement primitive with a short-circuit
+// condition based on the identified subgraph, its node pair mapping and its
+// basic blocks. The op argument specifies the short-circuit operator (token.LAND
+// or token.LOR). The new control flow primitive conceptually represents a basic
+// block with the given name.
+//
+// Contents of "if_and.dot":
+//
+//    digraph if_and {
+//       A [label="entry"]
+//       B
+//       C
+//       D [label="exit"]
+//       A->B [label="true"]
+//       A->D [label="false"]
+//       B->C [label="true"]
+//       B->D [label="false"]
+//       C->D
+//    }
+//
+// Contents of "if_or.dot":
+//
+//    digraph if_or {
+//       A [label="entry"]
+//       B
+//       C
+//       D [label="exit"]
+//       A->C [label="true"]
+//       A->B [label="false"]
+//       B->C [label="true"]
+//       B->D [label="false"]
+//       C->D
+//    }
+func createShortCircuitPrim(m map[string]string, bbs map[string]BasicBlock, newName string, op token.Token) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	nameC, ok := m["C"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "C"`)
+	}
+	nameD, ok := m["D"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "D"`)
+	}
+	bbCond1, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+	bbCond2, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+	bbBody, ok := bbs[nameC]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameC)
+	}
+	bbExit, ok := bbs[nameD]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameD)
+	}
+
+	// Create and return new primitive.
+	//
+	//    A
+	//    if A_cond && B_cond {
+	//       C
+	//    }
+	//    D
+	//
+	// or
+	//
+	//    A
+	//    if A_cond || B_cond {
+	//       C
+	//    }
+	//    D
+
+	// Locate the conditions. The first condition is oriented towards B for
+	// short-circuit AND and towards C for short-circuit OR, and the second
+	// condition is oriented towards C.
+	target1 := nameB
+	if op == token.LOR {
+		target1 = nameC
+	}
+	cond1, err := getCondTo(bbCond1, target1)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	cond2, err := getCondTo(bbCond2, nameC)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// The statements of B are only executed if the second condition is
+	// evaluated, and must therefore be fully expanded into the condition.
+	if len(bbCond2.Stmts()) != 0 {
+		return nil, errutil.Newf("support for short-circuit conditions with side effects not yet implemented; basic block %q contains %d statements", nameB, len(bbCond2.Stmts()))
+	}
+
+	// Create if-statement.
+	ifStmt := &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: cond1, Op: op, Y: cond2},
+		Body: &ast.BlockStmt{List: bbBody.Stmts()},
+	}
+
+	// Create primitive.
+	stmts := append(bbCond1.Stmts(), ifStmt)
+	stmts = append(stmts, bbExit.Stmts()...)
+	prim := &primitive{
+		blockData: blockData{name: newName, stmts: stmts, term: bbExit.Term()},
+	}
+	return prim, nil
+}
+
+// getCondTo returns the expanded branch condition of the basic block's
+// terminator, oriented so that the condition holds when control is transferred
+// to the given target.
+func getCondTo(bb BasicBlock, target string) (ast.Expr, error) {
+	cond, targetTrue, targetFalse, err := getBrCond(bb.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	cond = expandCond(bb, cond)
+	switch target {
+	case targetTrue:
+		return cond, nil
+	case targetFalse:
+		return &ast.UnaryExpr{Op: token.NOT, X: cond}, nil
+	default:
+		return nil, errutil.Newf("invalid branch targets; expected %q or %q, got %q", targetTrue, targetFalse, target)
+	}
+}
+
+// createIfElsePrim creates an if-else primitive based on the identified
+// subgraph, its node pair mapping and its basic blocks. The new control flow
+// primitive conceptually represents a basic block with the given name.
+//
+// Contents of "if_else.dot":
+//
+//    digraph if_else {
+//       A [label="entry"]
+//       B
+//       C
+//       D [label="exit"]
+//       A->B [label="true"]
+//       A->C [label="false"]
+//       B->D
+//       C->D
+//    }
+func createIfElsePrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	nameC, ok := m["C"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "C"`)
+	}
+	nameD, ok := m["D"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "D"`)
+	}
+	bbCond, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+
+	// The body nodes (B and C) of if-else primitives are indistinguishable at
+	// the graph level. Verify their names against the terminator instruction of
+	// the basic block and swap them if necessary.
+	cond, targetTrue, targetFalse, err := getBrCond(bbCond.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	if targetTrue != nameB && targetTrue != nameC {
+		return nil, errutil.Newf("invalid target true branch; got %q, expected %q or %q", targetTrue, nameB, nameC)
+	}
+	if targetFalse != nameB && targetFalse != nameC {
+		return nil, errutil.Newf("invalid target false branch; got %q, expected %q or %q", targetFalse, nameB, nameC)
+	}
+	nameB = targetTrue
+	nameC = targetFalse
+
+	bbBody1, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+	bbBody2, ok := bbs[nameC]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameC)
+	}
+	bbExit, ok := bbs[nameD]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameD)
+	}
+
+	// Create and return new primitive.
+	//
+	//    A
+	//    if A_cond {
+	//       B
+	//    } else {
+	//       C
+	//    }
+	//    D
+
+	// Create if-else statement.
+	cond = expandCond(bbCond, cond)
+	ifElseStmt := &ast.IfStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{List: bbBody1.Stmts()},
+		Else: &ast.BlockStmt{List: bbBody2.Stmts()},
+	}
+
+	// Create primitive.
+	stmts := append(bbCond.Stmts(), ifElseStmt)
+	stmts = append(stmts, bbExit.Stmts()...)
+	prim := &primitive{
+		blockData: blockData{name: newName, stmts: stmts, term: bbExit.Term()},
+	}
+	return prim, nil
+}
+
+// createPreLoopPrim creates a pre-test loop primitive based on the identified
+// subgraph, its node pair mapping and its basic blocks. The new control flow
+// primitive conceptually represents a basic block with the given name.
+//
+// Contents of "pre_loop.dot":
+//
+//    digraph pre_loop {
+//       A [label="entry"]
+//       B
+//       C [label="exit"]
+//       A->B [label="true"]
+//       B->A
+//       A->C [label="false"]
+//    }
+func createPreLoopPrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	nameC, ok := m["C"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "C"`)
+	}
+	bbCond, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+	bbBody, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+	bbExit, ok := bbs[nameC]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameC)
+	}
+
+	// Locate and expand the condition.
+	//
+	//    // from:
+	//    _2 := i < 10
+	//    if _2 {
+	//
+	//    // to:
+	//    if i < 10 {
+	cond, _, _, err := getBrCond(bbCond.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	cond, err = expand(bbCond, cond)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	return newPreLoopPrim(newName, bbCond, cond, bbBody.Stmts(), bbExit), nil
+}
+
+// newPreLoopPrim creates a pre-test loop primitive with the given name, based on
+// the condition basic block and its expanded condition, the loop body
+// statements and the exit basic block.
+func newPreLoopPrim(newName string, bbCond BasicBlock, cond ast.Expr, body []ast.Stmt, bbExit BasicBlock) *primitive {
+	if len(bbCond.Stmts()) != 0 {
+		// Produce the following primitive instead of a regular for loop if A
+		// contains statements.
+		//
+		//    for {
+		//       A
+		//       if !A_cond {
+		//          break
+		//       }
+		//       B
+		//    }
+		//    C
+
+		// Create if-statement.
+		ifStmt := &ast.IfStmt{
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: cond}, // negate condition
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.BranchStmt{Tok: token.BREAK}}},
+		}
+
+		// Create for-loop.
+		loopBody := append(bbCond.Stmts(), ifStmt)
+		loopBody = append(loopBody, body...)
+		forStmt := &ast.ForStmt{
+			Body: &ast.BlockStmt{List: loopBody},
+		}
+
+		// Create primitive.
+		stmts := []ast.Stmt{newLoop(forStmt, bbCond.Name(), bbExit.Name())}
+		stmts = append(stmts, bbExit.Stmts()...)
+		return &primitive{
+			blockData: blockData{name: newName, stmts: stmts, term: bbExit.Term()},
+		}
+	}
+
+	// Create and return new primitive.
+	//
+	//    for A_cond {
+	//       B
+	//    }
+	//    C
+
+	// Create for-loop.
+	forStmt := &ast.ForStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{List: body},
+	}
+
+	// Create primitive.
+	stmts := []ast.Stmt{newLoop(forStmt, bbCond.Name(), bbExit.Name())}
+	stmts = append(stmts, bbExit.Stmts()...)
+	return &primitive{
+		blockData: blockData{name: newName, stmts: stmts, term: bbExit.Term()},
+	}
+}
+
+// createPreLoopContinuePrim creates a pre-test loop primitive with a continue
+// statement based on the identified subgraph, its node pair mapping and its
+// basic blocks. The new control flow primitive conceptually represents a basic
+// block with the given name.
+//
+// Contents of "pre_loop_continue.dot":
+//
+//    digraph pre_loop_continue {
+//       A [label="entry"]
+//       B
+//       C
+//       D [label="exit"]
+//       A->B [label="true"]
+//       A->D [label="false"]
+//       B->A
+//       B->C
+//       C->A
+//    }
+func createPreLoopContinuePrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	nameC, ok := m["C"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "C"`)
+	}
+	nameD, ok := m["D"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "D"`)
+	}
+	bbCond, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+	bbBody1, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+	bbBody2, ok := bbs[nameC]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameC)
+	}
+	bbExit, ok := bbs[nameD]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameD)
+	}
+
+	// Create and return new primitive.
+	//
+	//    for A_cond {
+	//       B
+	//       if B_cond {
+	//          continue
+	//       }
+	//       C
+	//    }
+	//    D
+
+	// Locate and expand the loop condition.
+	cond, _, _, err := getBrCond(bbCond.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	cond, err = expand(bbCond, cond)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Create if-statement. The condition is negated if the branch back to the
+	// loop header is located at the false branch.
+	contCond, targetTrue, targetFalse, err := getBrCond(bbBody1.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	contCond = expandCond(bbBody1, contCond)
+	switch nameA {
+	case targetTrue:
+	case targetFalse:
+		contCond = &ast.UnaryExpr{Op: token.NOT, X: contCond}
+	default:
+		return nil, errutil.Newf("invalid branch targets; expected %q or %q, got %q", targetTrue, targetFalse, nameA)
+	}
+	ifStmt := &ast.IfStmt{
+		Cond: contCond,
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.BranchStmt{Tok: token.CONTINUE}}},
+	}
+
+	// Create primitive.
+	body := append(bbBody1.Stmts(), ifStmt)
+	body = append(body, bbBody2.Stmts()...)
+	return newPreLoopPrim(newName, bbCond, cond, body, bbExit), nil
+}
+
+// createPostLoopPrim creates a post-test loop primitive based on the identified
+// subgraph, its node pair mapping and its basic blocks. The new control flow
+// primitive conceptually represents a basic block with the given name.
+//
+// Contents of "post_loop.dot":
+//
+//    digraph post_loop {
+//       A [label="entry"]
+//       B [label="exit"]
+//       A->A [label="true"]
+//       A->B [label="false"]
+//    }
+func createPostLoopPrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	bbBody, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+	bbExit, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+
+	// Create and return new primitive.
+	//
+	//    for {
+	//       A
+	//       if !A_cond {
+	//          break
+	//       }
+	//    }
+	//    B
+
+	// Create if-statement. The condition is expanded as for pre-test loops, and
+	// negated if the exit is located at the false branch.
+	//
+	//    // from:
+	//    _2 := i < 10
+	//    if !_2 {
+	//
+	//    // to:
+	//    if !(i < 10) {
+	cond, targetTrue, targetFalse, err := getBrCond(bbBody.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	cond = expandCond(bbBody, cond)
+	switch nameB {
+	case targetTrue:
+	case targetFalse:
+		cond = &ast.UnaryExpr{Op: token.NOT, X: cond}
+	default:
+		return nil, errutil.Newf("invalid branch targets; expected %q or %q, got %q", targetTrue, targetFalse, nameB)
+	}
+	ifStmt := &ast.IfStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.BranchStmt{Tok: token.BREAK}}},
+	}
+
+	// Create for-loop.
+	body := bbBody.Stmts()
+	body = append(body, ifStmt)
+	forStmt := &ast.ForStmt{
+		Body: &ast.BlockStmt{List: body},
+	}
+
+	// Create primitive.
+	stmts := []ast.Stmt{newLoop(forStmt, bbBody.Name(), bbExit.Name())}
+	stmts = append(stmts, bbExit.Stmts()...)
+	prim := &primitive{
+		blockData: blockData{name: newName, stmts: stmts, term: bbExit.Term()},
+	}
+	return prim, nil
+}
+
+// createNestedPreLoopBreakPrim creates two nested pre-test loop primitives, in
+// which the inner loop may break out of the outer loop, based on the identified
+// subgraph, its node pair mapping and its basic blocks. The new control flow
+// primitive conceptually represents a basic block with the given name.
+//
+// Contents of "nested_pre_loop_break.dot":
+//
+//    digraph nested_pre_loop_break {
+//       A [label="entry"]
+//       B
+//       C
+//       D
+//       E [label="exit"]
+//       A->B [label="true"]
+//       A->E [label="false"]
+//       B->C [label="true"]
+//       B->D [label="false"]
+//       C->B
+//       C->E
+//       D->A
+//    }
+func createNestedPreLoopBreakPrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	nameC, ok := m["C"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "C"`)
+	}
+	nameD, ok := m["D"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "D"`)
+	}
+	nameE, ok := m["E"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "E"`)
+	}
+	bbOuterCond, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+	bbInnerCond, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+	bbInnerBody, ok := bbs[nameC]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameC)
+	}
+	bbOuterBody, ok := bbs[nameD]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameD)
+	}
+	bbExit, ok := bbs[nameE]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameE)
+	}
+
+	// Create and return new primitive.
+	//
+	//    loop0:
+	//    for A_cond {
+	//       for B_cond {
+	//          C
+	//          if C_cond {
+	//             break loop0
+	//          }
+	//       }
+	//       D
+	//    }
+	//    E
+
+	// Locate and expand the loop conditions.
+	outerCond, _, _, err := getBrCond(bbOuterCond.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	outerCond, err = expand(bbOuterCond, outerCond)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	innerCond, _, _, err := getBrCond(bbInnerCond.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	innerCond, err = expand(bbInnerCond, innerCond)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+
+	// Create if-statement which breaks out of the outer loop. The condition is
+	// negated if the branch to the exit is located at the false branch.
+	breakCond, targetTrue, targetFalse, err := getBrCond(bbInnerBody.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	breakCond = expandCond(bbInnerBody, breakCond)
+	switch nameE {
+	case targetTrue:
+	case targetFalse:
+		breakCond = &ast.UnaryExpr{Op: token.NOT, X: breakCond}
+	default:
+		return nil, errutil.Newf("invalid branch targets; expected %q or %q, got %q", targetTrue, targetFalse, nameE)
+	}
+	ifStmt := &ast.IfStmt{
+		Cond: breakCond,
+		Body: &ast.BlockStmt{List: []ast.Stmt{newBranch(token.BREAK, nameE)}},
+	}
+
+	// Create the inner loop, followed by the outer loop.
+	innerBody := append(bbInnerBody.Stmts(), ifStmt)
+	inner := newPreLoopPrim(nameB, bbInnerCond, innerCond, innerBody, bbOuterBody)
+	return newPreLoopPrim(newName, bbOuterCond, outerCond, inner.Stmts(), bbExit), nil
+}
+
+// createSwitchPrim creates a switch-statement primitive based on the identified
+// subgraph, its node pair mapping and its basic blocks. The new control flow
+// primitive conceptually represents a basic block with the given name.
+//
+// The number of case nodes varies between switch statements. The entry and exit
+// nodes are always named "A" and "B" respectively, and every other sub node
+// represents the body of a case or the default case. Cases without a body branch
+// directly from "A" to "B".
+//
+// One subgraph is provided per supported number of case nodes; switch
+// statements with 3 to 8 case nodes ("switch_3.dot" to "switch_8.dot"), and with
+// 2 to 7 case nodes and a direct branch to the exit node ("switch_exit_2.dot" to
+// "switch_exit_7.dot"). Switch terminators with fewer successors share the
+// subgraphs of 2-way conditionals, and larger switch statements are left
+// unstructured (see the Goto option).
+//
+// Contents of "switch_3.dot":
+//
+//    digraph switch {
+//       A [label="entry"]
+//       B [label="exit"]
+//       C
+//       D
+//       E
+//       A->C
+//       A->D
+//       A->E
+//       C->B
+//       D->B
+//       E->B
+//    }
+func createSwitchPrim(m map[string]string, bbs map[string]BasicBlock, newName string) (*primitive, error) {
+	// Locate graph nodes.
+	nameA, ok := m["A"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "A"`)
+	}
+	nameB, ok := m["B"]
+	if !ok {
+		return nil, errutil.New(`unable to locate node pair for sub node "B"`)
+	}
+	bbCond, ok := bbs[nameA]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameA)
+	}
+	bbExit, ok := bbs[nameB]
+	if !ok {
+		return nil, errutil.Newf("unable to locate basic block %q", nameB)
+	}
+
+	// Create and return new primitive.
+	//
+	//    A
+	//    switch A_cond {
+	//    case 1:
+	//       C
+	//    case 2:
+	//       D
+	//    default:
+	//       E
+	//    }
+	//    B
+
+	// getBody returns the statements of the target basic block; targets which
+	// branch directly to the exit basic block have empty bodies.
+	getBody := func(target string) ([]ast.Stmt, error) {
+		if target == nameB {
+			return nil, nil
+		}
+		bb, ok := bbs[target]
+		if !ok {
+			return nil, errutil.Newf("unable to locate basic block %q", target)
+		}
+		return bb.Stmts(), nil
+	}
+
+	// Create case clauses. Case values which share the same target basic block
+	// are merged into a single case clause.
+	cond, targetDefault, cases, err := getSwitchCases(bbCond.Term())
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	var clauses []ast.Stmt
+	targetClause := make(map[string]*ast.CaseClause)
+	for _, c := range cases {
+		if clause, ok := targetClause[c.target]; ok {
+			clause.List = append(clause.List, c.val)
+			continue
+		}
+		body, err := getBody(c.target)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		clause := &ast.CaseClause{
+			List: []ast.Expr{c.val},
+			Body: body,
+		}
+		targetClause[c.target] = clause
+		clauses = append(clauses, clause)
+	}
+
+	// Create default clause. If the default target is also the target of a
+	// case clause, the case clause is turned into the default clause, as the
+	// statements of a basic block may only be used once and Go has no syntax
+	// for case clauses which are also the default; the case values are covered
+	// by the default clause, as no other case clause matches them.
+	//
+	//    // from:
+	//    switch i32 %x, label %a [i32 1, label %a
+	//                             i32 2, label %b]
+	//
+	//    // to:
+	//    switch x {
+	//    default:
+	//       a
+	//    case 2:
+	//       b
+	//    }
+	if clause, ok := targetClause[targetDefault]; ok {
+		clause.List = nil
+	} else {
+		body, err := getBody(targetDefault)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		if len(body) > 0 {
+			clauses = append(clauses, &ast.CaseClause{Body: body})
+		}
+	}
+
+	// Create switch-statement.
+	switchStmt := &ast.SwitchStmt{
+		Tag:  cond,
+		Body: &ast.BlockStmt{List: clauses},
+	}
+
+	// Create primitive.
+	stmts := append(bbCond.Stmts(), switchStmt)
+	stmts = append(stmts, bbExit.Stmts()...)
+	prim := &primitive{
+		blockData: blockData{name: newName, stmts: stmts, term: bbExit.Term()},
+	}
+	return prim, nil
+}
+
+// newBranch returns a new break or continue statement which targets the loop of
+// the given basic block; i.e. the exit basic block of the loop for break
+// statements, and the header basic block of the loop for continue statements.
+// The target is resolved by newLoop once the targeted loop has been created.
+func newBranch(tok token.Token, target string) *ast.BranchStmt {
+	return &ast.BranchStmt{Tok: tok, Label: ast.NewIdent(target)}
+}
+
+// newLoop resolves the targeted break and continue statements (see newBranch)
+// of the for-loop body which target the loop, based on the names of its header
+// and exit basic blocks. Branch statements which target the loop from within
+// nested loops (or break statements from within nested switch statements) refer
+// to the loop using a label, in which case the labeled loop is returned.
+// Otherwise, the label of the branch statement is removed and the unlabeled loop
+// is returned.
+func newLoop(forStmt *ast.ForStmt, header, exit string) ast.Stmt {
+	// The label is given a name by labelLoops once the function body has been
+	// structured.
+	label := ast.NewIdent("")
+	v := &branchResolver{header: header, exit: exit, label: label}
+	ast.Walk(v, forStmt.Body)
+	if !v.labeled {
+		return forStmt
+	}
+	return &ast.LabeledStmt{Label: label, Stmt: forStmt}
+}
+
+// branchResolver resolves the targeted break and continue statements of a loop
+// body.
+type branchResolver struct {
+	// Names of the header and exit basic blocks of the loop.
+	header, exit string
+	// Label of the loop.
+	label *ast.Ident
+	// Nesting depth of loops and switch statements within the loop body.
+	loops, switches int
+	// labeled is set to true if any branch statement refers to the loop label.
+	labeled bool
+}
+
+// Visit resolves the targeted break and continue statements of the node.
+func (v *branchResolver) Visit(n ast.Node) ast.Visitor {
+	switch n := n.(type) {
+	case *ast.FuncLit:
+		return nil
+	case *ast.ForStmt, *ast.RangeStmt:
+		w := *v
+		w.loops++
+		return &childResolver{parent: v, branchResolver: w}
+	case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+		w := *v
+		w.switches++
+		return &childResolver{parent: v, branchResolver: w}
+	case *ast.BranchStmt:
+		// Ignore unlabeled branches and branches referring to loop labels.
+		if n.Label == nil || len(n.Label.Name) == 0 {
+			break
+		}
+		switch {
+		case n.Tok == token.BREAK && n.Label.Name == v.exit:
+			if v.loops > 0 || v.switches > 0 {
+				n.Label = v.label
+				v.labeled = true
+			} else {
+				n.Label = nil
+			}
+		case n.Tok == token.CONTINUE && n.Label.Name == v.header:
+			if v.loops > 0 {
+				n.Label = v.label
+				v.labeled = true
+			} else {
+				n.Label = nil
+			}
+		}
+	}
+	return v
+}
+
+// childResolver resolves the branch statements of a nested loop or switch
+// statement, and propagates the use of the loop label to its parent.
+type childResolver struct {
+	parent *branchResolver
+	branchResolver
+}
+
+// Visit resolves the targeted break and continue statements of the node.
+func (v *childResolver) Visit(n ast.Node) ast.Visitor {
+	if n == nil {
+		v.parent.labeled = v.parent.labeled || v.labeled
+		return nil
+	}
+	return v.branchResolver.Visit(n)
+}
+
+// labelLoops names the labels of the labeled loops within the function body.
+// Loop labels are numbered in the order the loops appear (e.g. "loop0",
+// "loop1").
+func labelLoops(body *ast.BlockStmt) {
+	n := 0
+	ast.Inspect(body, func(node ast.Node) bool {
+		if stmt, ok := node.(*ast.LabeledStmt); ok && len(stmt.Label.Name) == 0 {
+			stmt.Label.Name = fmt.Sprintf("loop%d", n)
+			n++
+		}
+		return true
+	})
+}
+
+// printMapping prints the mapping from sub node name to graph node name for an
+// isomorphism of sub in graph to the debug logger.
+func printMapping(graph *dot.Graph, sub *graphs.SubGraph, m map[string]string) {
+	entry := m[sub.Entry()]
+	var snames []string
+	for sname := range m {
+		snames = append(snames, sname)
+	}
+	sort.Strings(snames)
+	Debug.Printf("Isomorphism of %q found at node %q:\n", sub.Name, entry)
+	for _, sname := range snames {
+		Debug.Printf("   %q=%q\n", sname, m[sname])
+	}
+}
@@ -0,0 +1,177 @@
+package decomp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	xprimitive "decomp.org/x/graphs/primitive"
+)
+
+func TestMergeListChains(t *testing.T) {
+	list := func(node string, names ...string) *xprimitive.Primitive {
+		m := make(map[string]string)
+		for i, name := range names {
+			m[string('A'+rune(i))] = name
+		}
+		return &xprimitive.Primitive{Prim: "list", Nodes: m, Node: node}
+	}
+	ifPrim := &xprimitive.Primitive{Prim: "if", Nodes: map[string]string{"A": "list1", "B": "3", "C": "4"}, Node: "if0"}
+	golden := []struct {
+		hprims []*xprimitive.Primitive
+		want   []*xprimitive.Primitive
+	}{
+		// Chain which grows at the end.
+		{
+			hprims: []*xprimitive.Primitive{list("list0", "0", "1"), list("list1", "list0", "2")},
+			want:   []*xprimitive.Primitive{list("list1", "0", "1", "2")},
+		},
+		// Chain which grows at the start.
+		{
+			hprims: []*xprimitive.Primitive{list("list0", "1", "2"), list("list1", "0", "list0")},
+			want:   []*xprimitive.Primitive{list("list1", "0", "1", "2")},
+		},
+		// Unrelated list primitives.
+		{
+			hprims: []*xprimitive.Primitive{list("list0", "0", "1"), list("list1", "2", "3")},
+			want:   []*xprimitive.Primitive{list("list0", "0", "1"), list("list1", "2", "3")},
+		},
+		// Chain interrupted by another primitive.
+		{
+			hprims: []*xprimitive.Primitive{list("list0", "0", "1"), list("list1", "list0", "2"), ifPrim, list("list2", "if0", "4")},
+			want:   []*xprimitive.Primitive{list("list1", "0", "1", "2"), ifPrim, list("list2", "if0", "4")},
+		},
+	}
+	for i, g := range golden {
+		got := mergeListChains(g.hprims)
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("i=%d: primitives mismatch; expected %v, got %v", i, sprintPrims(g.want), sprintPrims(got))
+		}
+	}
+
+	// Chains are limited to the sub nodes "A" through "Z".
+	hprims := []*xprimitive.Primitive{list("list0", "0", "1")}
+	for i := 1; i < 30; i++ {
+		hprims = append(hprims, list(fmt.Sprintf("list%d", i), hprims[i-1].Node, "x"))
+	}
+	got := mergeListChains(hprims)
+	if len(got) != 2 || len(got[0].Nodes) != 26 || len(got[1].Nodes) != 6 {
+		t.Errorf("chain limit mismatch; expected list primitives of 26 and 6 nodes, got %v", sprintPrims(got))
+	}
+}
+
+// sprintPrims returns a string representation of the given primitives.
+func sprintPrims(hprims []*xprimitive.Primitive) []string {
+	var s []string
+	for _, hprim := range hprims {
+		s = append(s, fmt.Sprintf("%s(%s) -> %s", hprim.Prim, strings.Join(listNodes(hprim.Nodes), ", "), hprim.Node))
+	}
+	return s
+}
+
+func TestIsTerminating(t *testing.T) {
+	golden := []struct {
+		src  string
+		want bool
+	}{
+		{src: "return", want: true},
+		{src: "panic(\"unreachable\")", want: true},
+		{src: "f()", want: false},
+		{src: "for {\nf()\n}", want: true},
+		{src: "for {\nif c {\nbreak\n}\n}", want: false},
+		{src: "for {\nif c {\nbreak exit\n}\n}", want: false},
+		{src: "for {\nif c {\ngoto exit\n}\n}", want: false},
+		{src: "for {\nswitch c {\ncase 1:\nbreak\n}\n}", want: true},
+		{src: "for c {\n}", want: false},
+		{src: "loop:\nfor {\n}", want: true},
+		{src: "if c {\nreturn\n} else {\npanic(0)\n}", want: true},
+		{src: "if c {\nreturn\n}", want: false},
+		{src: "{\nf()\nreturn\n}", want: true},
+	}
+	for _, g := range golden {
+		f, err := parseTestFunc("func f() {\n" + g.src + "\n}")
+		if err != nil {
+			t.Errorf("%q: %v", g.src, err)
+			continue
+		}
+		if got := isTerminating(f.Body.List[0]); got != g.want {
+			t.Errorf("%q: expected %v, got %v", g.src, g.want, got)
+		}
+	}
+}
+
+func TestCreateIfReturnPrimUnreachable(t *testing.T) {
+	const src = `
+define i32 @f(i32 %x) {
+entry:
+  %c = icmp slt i32 %x, 0
+  br i1 %c, label %fail, label %ok
+
+fail:
+  call void @abort()
+  unreachable
+
+ok:
+  ret i32 %x
+}
+
+declare void @abort() noreturn
+`
+	got, err := decompileTest(src, Options{}, func(funcName string) []*xprimitive.Primitive {
+		return []*xprimitive.Primitive{
+			{Prim: "if_return", Nodes: map[string]string{"A": "entry", "B": "fail", "C": "ok"}, Node: "if0"},
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+func f(x int32) int32 {
+	if x < 0 {
+		abort()
+		panic("unreachable")
+	}
+	return x
+}
+`
+	if got != want {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+}
+
+func TestRestructureIrreducible(t *testing.T) {
+	// The loop of a and b has two entry points, and is therefore not reducible
+	// to control flow primitives.
+	const src = `
+define i32 @f(i32 %x) {
+entry:
+  %c = icmp slt i32 %x, 0
+  br i1 %c, label %a, label %b
+
+a:
+  %i = phi i32 [ %x, %entry ], [ %j, %b ]
+  %ca = icmp sgt i32 %i, 10
+  br i1 %ca, label %exit, label %b
+
+b:
+  %j = phi i32 [ %x, %entry ], [ %i, %a ]
+  %cb = icmp sgt i32 %j, 20
+  br i1 %cb, label %exit, label %a
+
+exit:
+  ret i32 %x
+}
+`
+	_, err := decompileTest(src, Options{}, func(funcName string) []*xprimitive.Primitive {
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	want := "4 nodes remain (a, b, entry, exit); set the Goto option to emit goto statements"
+	if got := err.Error(); !strings.Contains(got, want) {
+		t.Errorf("error mismatch; expected %q in %q", want, got)
+	}
+}
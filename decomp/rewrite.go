@@ -0,0 +1,94 @@
+package decomp
+
+import (
+	"go/ast"
+	"reflect"
+)
+
+// rewriteExprs replaces each expression within the provided node with the
+// result of f, which is invoked on the sub-expressions of an expression before
+// the expression itself. Replacement expressions are parenthesized where
+// required by their parent expression.
+//
+//    // from:
+//    int32(x + y) * z
+//
+//    // to (f replaces the conversion with its argument):
+//    (x + y) * z
+func rewriteExprs(n ast.Node, f func(expr ast.Expr) ast.Expr) {
+	rewriteValue(reflect.ValueOf(n), n, f)
+}
+
+var (
+	// exprIface is the interface type of expressions.
+	exprIface = reflect.TypeOf((*ast.Expr)(nil)).Elem()
+	// Types of the nodes which are not rewritten.
+	commentGroupType = reflect.TypeOf((*ast.CommentGroup)(nil))
+	objectType       = reflect.TypeOf((*ast.Object)(nil))
+	scopeType        = reflect.TypeOf((*ast.Scope)(nil))
+)
+
+// rewriteValue rewrites the expressions of the node or the node field held by
+// v, which is part of the given parent node.
+func rewriteValue(v reflect.Value, parent ast.Node, f func(expr ast.Expr) ast.Expr) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		rewriteValue(v.Elem(), parent, f)
+		if v.Type() != exprIface || !v.CanSet() {
+			return
+		}
+		old := v.Interface().(ast.Expr)
+		if x := f(old); x != old {
+			if needsParens(parent, x) {
+				x = &ast.ParenExpr{X: x}
+			}
+			v.Set(reflect.ValueOf(x))
+		}
+	case reflect.Ptr:
+		switch v.Type() {
+		case commentGroupType, objectType, scopeType:
+			return
+		}
+		if v.IsNil() {
+			return
+		}
+		if n, ok := v.Interface().(ast.Node); ok {
+			parent = n
+		}
+		rewriteValue(v.Elem(), parent, f)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			rewriteValue(v.Index(i), parent, f)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			rewriteValue(v.Field(i), parent, f)
+		}
+	}
+}
+
+// needsParens returns true if the provided expression must be parenthesized as
+// an operand of the given parent node, and false otherwise.
+//
+//    (x + y) * z
+//    (-x).f
+func needsParens(parent ast.Node, x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.BinaryExpr:
+		switch parent := parent.(type) {
+		case *ast.BinaryExpr:
+			return x.Op.Precedence() <= parent.Op.Precedence()
+		case *ast.UnaryExpr, *ast.StarExpr, *ast.SelectorExpr, *ast.IndexExpr, *ast.SliceExpr, *ast.TypeAssertExpr:
+			return true
+		}
+	case *ast.UnaryExpr, *ast.StarExpr:
+		switch parent.(type) {
+		case *ast.SelectorExpr, *ast.IndexExpr, *ast.SliceExpr, *ast.TypeAssertExpr:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// reduceSwitchChains replaces chains of if-else statements which compare the
+// same variable for equality against distinct constants with equivalent switch
+// statements. Compilers may lower switch statements into such comparison chains
+// rather than into switch instructions.
+//
+//    // from:
+//    if x == 1 {
+//       A
+//    } else if x == 2 {
+//       B
+//    } else {
+//       C
+//    }
+//
+//    // to:
+//    switch x {
+//    case 1:
+//       A
+//    case 2:
+//       B
+//    default:
+//       C
+//    }
+func reduceSwitchChains(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		var stmts []ast.Stmt
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			stmts = n.List
+		case *ast.CaseClause:
+			stmts = n.Body
+		}
+		for i, stmt := range stmts {
+			ifStmt, ok := stmt.(*ast.IfStmt)
+			if !ok {
+				continue
+			}
+			if switchStmt, ok := newSwitchChain(ifStmt); ok {
+				stmts[i] = switchStmt
+			}
+		}
+		return true
+	})
+}
+
+// newSwitchChain returns a switch statement equivalent to the chain of if-else
+// statements starting at the provided if statement, and a boolean indicating
+// success. At least two equality comparisons of the same variable are required.
+func newSwitchChain(ifStmt *ast.IfStmt) (*ast.SwitchStmt, bool) {
+	var tag *ast.Ident
+	var clauses []ast.Stmt
+	vals := make(map[string]bool)
+	for ifStmt != nil {
+		x, val, ok := getEqualityOperands(ifStmt.Cond)
+		if !ok || ifStmt.Init != nil || (tag != nil && x.Name != tag.Name) || vals[val.Value] {
+			if len(clauses) == 0 {
+				return nil, false
+			}
+			// The remainder of the chain is the default case.
+			clauses = append(clauses, &ast.CaseClause{Body: []ast.Stmt{ifStmt}})
+			break
+		}
+		tag = x
+		vals[val.Value] = true
+		clauses = append(clauses, &ast.CaseClause{List: []ast.Expr{val}, Body: ifStmt.Body.List})
+
+		// Locate the next if statement of the chain.
+		next := ifStmt.Else
+		if block, ok := next.(*ast.BlockStmt); ok && len(block.List) == 1 {
+			if elseIf, ok := block.List[0].(*ast.IfStmt); ok {
+				next = elseIf
+			}
+		}
+		ifStmt = nil
+		switch next := next.(type) {
+		case *ast.IfStmt:
+			ifStmt = next
+		case *ast.BlockStmt:
+			clauses = append(clauses, &ast.CaseClause{Body: next.List})
+		}
+	}
+	if len(vals) < 2 {
+		return nil, false
+	}
+
+	// Unlabeled break statements would refer to the switch statement rather
+	// than to the enclosing loop.
+	for _, clause := range clauses {
+		if hasUnlabeledBreak(clause.(*ast.CaseClause).Body) {
+			return nil, false
+		}
+	}
+	switchStmt := &ast.SwitchStmt{
+		Tag:  tag,
+		Body: &ast.BlockStmt{List: clauses},
+	}
+	return switchStmt, true
+}
+
+// getEqualityOperands returns the variable and constant operands of the
+// provided equality comparison (e.g. "x == 1" or "1 == x"), and a boolean
+// indicating success.
+func getEqualityOperands(cond ast.Expr) (x *ast.Ident, val *ast.BasicLit, ok bool) {
+	expr, ok := cond.(*ast.BinaryExpr)
+	if !ok || expr.Op != token.EQL {
+		return nil, nil, false
+	}
+	if x, ok := expr.X.(*ast.Ident); ok {
+		if val, ok := expr.Y.(*ast.BasicLit); ok {
+			return x, val, true
+		}
+	}
+	if x, ok := expr.Y.(*ast.Ident); ok {
+		if val, ok := expr.X.(*ast.BasicLit); ok {
+			return x, val, true
+		}
+	}
+	return nil, nil, false
+}
+
+// hasUnlabeledBreak returns true if any of the provided statements contains an
+// unlabeled break statement which is not nested within a loop, switch or
+// select statement, and false otherwise.
+func hasUnlabeledBreak(stmts []ast.Stmt) bool {
+	found := false
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.FuncLit:
+				return false
+			case *ast.BranchStmt:
+				if n.Tok == token.BREAK && (n.Label == nil || len(n.Label.Name) == 0) {
+					found = true
+				}
+			}
+			return !found
+		})
+	}
+	return found
+}
@@ -0,0 +1,104 @@
+package decomp
+
+import (
+	"testing"
+
+	xprimitive "decomp.org/x/graphs/primitive"
+)
+
+func TestReduceSwitchChains(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Chain with an else branch.
+		{
+			src:  "func f(x int32) {\nif x == 1 {\na()\n} else if 2 == x {\nb()\n} else {\nc()\n}\n}",
+			want: "func f(x int32) {\n\tswitch x {\n\tcase 1:\n\t\ta()\n\tcase 2:\n\t\tb()\n\tdefault:\n\t\tc()\n\t}\n}",
+		},
+		// Chain without an else branch.
+		{
+			src:  "func f(x int32) {\nif x == 1 {\na()\n} else if x == 2 {\nb()\n}\n}",
+			want: "func f(x int32) {\n\tswitch x {\n\tcase 1:\n\t\ta()\n\tcase 2:\n\t\tb()\n\t}\n}",
+		},
+		// Single comparison.
+		{
+			src:  "func f(x int32) {\nif x == 1 {\na()\n} else {\nb()\n}\n}",
+			want: "func f(x int32) {\n\tif x == 1 {\n\t\ta()\n\t} else {\n\t\tb()\n\t}\n}",
+		},
+		// Duplicate constants and other variables end the chain.
+		{
+			src:  "func f(x, y int32) {\nif x == 1 {\na()\n} else if x == 2 {\nb()\n} else if x == 1 {\nc()\n}\nif x == 1 {\na()\n} else if x == 2 {\nb()\n} else if y == 3 {\nc()\n}\n}",
+			want: "func f(x, y int32) {\n\tswitch x {\n\tcase 1:\n\t\ta()\n\tcase 2:\n\t\tb()\n\tdefault:\n\t\tif x == 1 {\n\t\t\tc()\n\t\t}\n\t}\n\tswitch x {\n\tcase 1:\n\t\ta()\n\tcase 2:\n\t\tb()\n\tdefault:\n\t\tif y == 3 {\n\t\t\tc()\n\t\t}\n\t}\n}",
+		},
+		// Unlabeled break statements of an enclosing loop.
+		{
+			src:  "func f(x int32) {\nfor {\nif x == 1 {\nbreak\n} else if x == 2 {\nb()\n}\n}\n}",
+			want: "func f(x int32) {\n\tfor {\n\t\tif x == 1 {\n\t\t\tbreak\n\t\t} else if x == 2 {\n\t\t\tb()\n\t\t}\n\t}\n}",
+		},
+		// Break statements of nested loops.
+		{
+			src:  "func f(x int32) {\nif x == 1 {\nfor {\nbreak\n}\n} else if x == 2 {\nb()\n}\n}",
+			want: "func f(x int32) {\n\tswitch x {\n\tcase 1:\n\t\tfor {\n\t\t\tbreak\n\t\t}\n\tcase 2:\n\t\tb()\n\t}\n}",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		reduceSwitchChains(f.Body)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+func TestCreateSwitchPrimSharedDefault(t *testing.T) {
+	const src = `
+define i32 @f(i32 %x) {
+entry:
+  switch i32 %x, label %a [
+    i32 1, label %a
+    i32 2, label %b
+  ]
+
+a:
+  call void @g(i32 1)
+  br label %exit
+
+b:
+  call void @g(i32 2)
+  br label %exit
+
+exit:
+  ret i32 %x
+}
+
+declare void @g(i32)
+`
+	got, err := decompileTest(src, Options{}, func(funcName string) []*xprimitive.Primitive {
+		return []*xprimitive.Primitive{
+			{Prim: "switch", Nodes: map[string]string{"A": "entry", "B": "exit", "C": "a", "D": "b"}, Node: "switch0"},
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+func f(x int32) int32 {
+	switch x {
+	default:
+		g(1)
+	case 2:
+		g(2)
+	}
+	return x
+}
+`
+	if got != want {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+}
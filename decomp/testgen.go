@@ -0,0 +1,186 @@
+package decomp
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CreateTestFile returns a companion Go test file of the provided decompiled Go
+// source file, with a table-driven test stub for each function declaration
+// (except main and init). The table of each test stub contains a single empty
+// test case, which calls the function with zero-value arguments; further test
+// cases are to be added by the user, as noted by a comment positioned in the
+// given file set.
+//
+// Example:
+//    func TestAdd(t *testing.T) {
+//       // TODO: Add test cases.
+//       golden := []struct {
+//          x    int32
+//          y    int32
+//          want int32
+//       }{{}}
+//       for _, g := range golden {
+//          got := add(g.x, g.y)
+//          if !reflect.DeepEqual(got, g.want) {
+//             t.Errorf("add(%v, %v): expected %v, got %v", g.x, g.y, g.want, got)
+//          }
+//       }
+//    }
+func CreateTestFile(fset *token.FileSet, file *ast.File) *ast.File {
+	testFile := &ast.File{
+		Name: ast.NewIdent(file.Name.Name),
+	}
+	used := make(map[string]bool)
+	comments := make(commentMap)
+	for _, decl := range file.Decls {
+		f, ok := decl.(*ast.FuncDecl)
+		if !ok || f.Recv != nil || f.Name.Name == "main" || f.Name.Name == "init" {
+			continue
+		}
+		testName := getTestName(f.Name.Name)
+		name := testName
+		for i := 1; used[name]; i++ {
+			name = fmt.Sprintf("%s_%d", testName, i)
+		}
+		used[name] = true
+		testFile.Decls = append(testFile.Decls, createTestFunc(f, name, comments))
+	}
+	positionComments(fset, testFile, comments)
+	addImports(testFile)
+	return testFile
+}
+
+// getTestName returns the name of the test function of the provided function
+// (e.g. "TestAdd" for "add").
+func getTestName(funcName string) string {
+	r, size := utf8.DecodeRuneInString(funcName)
+	return "Test" + string(unicode.ToUpper(r)) + funcName[size:]
+}
+
+// createTestFunc creates a table-driven test stub with the given name, which
+// calls the provided function. The comment lines of the test stub are recorded
+// in comments.
+func createTestFunc(f *ast.FuncDecl, testName string, comments commentMap) *ast.FuncDecl {
+	// Local identifiers of the test function, which must not shadow the tested
+	// function.
+	local := func(name string) *ast.Ident {
+		if name == f.Name.Name {
+			name += "_"
+		}
+		return ast.NewIdent(name)
+	}
+	t, golden, g, got := local("t"), local("golden"), local("g"), local("got")
+
+	// Create the fields of the test case table, one per parameter and one for
+	// the expected result.
+	//    x    int32
+	//    want int32
+	fields := &ast.FieldList{}
+	var args, fmtArgs []ast.Expr
+	var verbs []string
+	i := 0
+	for _, param := range f.Type.Params.List {
+		if _, ok := param.Type.(*ast.Ellipsis); ok {
+			// Variadic arguments are omitted.
+			continue
+		}
+		names := param.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent("_")}
+		}
+		for _, name := range names {
+			fieldName := name.Name
+			if fieldName == "_" {
+				fieldName = "arg" + strconv.Itoa(i)
+			}
+			i++
+			fields.List = append(fields.List, &ast.Field{Names: []*ast.Ident{ast.NewIdent(fieldName)}, Type: param.Type})
+			arg := &ast.SelectorExpr{X: g, Sel: ast.NewIdent(fieldName)}
+			args = append(args, arg)
+			fmtArgs = append(fmtArgs, arg)
+			verbs = append(verbs, "%v")
+		}
+	}
+	call := &ast.CallExpr{Fun: ast.NewIdent(f.Name.Name), Args: args}
+	hasResult := f.Type.Results != nil && len(f.Type.Results.List) > 0
+	want := "want"
+	if hasResult {
+		for _, field := range fields.List {
+			if field.Names[0].Name == want {
+				want += "_"
+			}
+		}
+		fields.List = append(fields.List, &ast.Field{Names: []*ast.Ident{ast.NewIdent(want)}, Type: f.Type.Results.List[0].Type})
+	}
+
+	// Create the test case table.
+	//    golden := []struct{...}{{}}
+	table := &ast.CompositeLit{
+		Type: &ast.ArrayType{Elt: &ast.StructType{Fields: fields}},
+		Elts: []ast.Expr{&ast.CompositeLit{}},
+	}
+	tableStmt := &ast.AssignStmt{Lhs: []ast.Expr{golden}, Tok: token.DEFINE, Rhs: []ast.Expr{table}}
+
+	// Create the loop body, which calls the function and compares its result
+	// against the expected result.
+	//    got := add(g.x, g.y)
+	//    if !reflect.DeepEqual(got, g.want) {
+	//       t.Errorf("add(%v, %v): expected %v, got %v", g.x, g.y, g.want, got)
+	//    }
+	var loopBody []ast.Stmt
+	if hasResult {
+		wantExpr := &ast.SelectorExpr{X: g, Sel: ast.NewIdent(want)}
+		deepEqual := &ast.CallExpr{
+			Fun:  newPkgSelector("reflect", "DeepEqual"),
+			Args: []ast.Expr{got, wantExpr},
+		}
+		format := fmt.Sprintf("%s(%s): expected %%v, got %%v", f.Name.Name, strings.Join(verbs, ", "))
+		errorf := &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: t, Sel: ast.NewIdent("Errorf")},
+			Args: append(append([]ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(format)}}, fmtArgs...), wantExpr, got),
+		}
+		loopBody = []ast.Stmt{
+			&ast.AssignStmt{Lhs: []ast.Expr{got}, Tok: token.DEFINE, Rhs: []ast.Expr{call}},
+			&ast.IfStmt{
+				Cond: &ast.UnaryExpr{Op: token.NOT, X: deepEqual},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: errorf}}},
+			},
+		}
+	} else {
+		loopBody = []ast.Stmt{&ast.ExprStmt{X: call}}
+	}
+	loop := &ast.RangeStmt{
+		Key:   ast.NewIdent("_"),
+		Value: g,
+		Tok:   token.DEFINE,
+		X:     golden,
+		Body:  &ast.BlockStmt{List: loopBody},
+	}
+
+	// Create the test function.
+	//    func TestAdd(t *testing.T)
+	sig := &ast.FuncType{
+		Params: &ast.FieldList{
+			List: []*ast.Field{{
+				Names: []*ast.Ident{t},
+				Type:  &ast.StarExpr{X: newPkgSelector("testing", "T")},
+			}},
+		},
+	}
+	if !hasResult && len(args) == 0 {
+		// The test case table is of no use without parameters and results.
+		body := &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: call}}}
+		return &ast.FuncDecl{Name: ast.NewIdent(testName), Type: sig, Body: body}
+	}
+	comments[tableStmt] = []string{"TODO: Add test cases."}
+	body := &ast.BlockStmt{
+		List: []ast.Stmt{tableStmt, loop},
+	}
+	return &ast.FuncDecl{Name: ast.NewIdent(testName), Type: sig, Body: body}
+}
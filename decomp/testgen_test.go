@@ -0,0 +1,86 @@
+package decomp
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"testing"
+)
+
+func TestCreateTestFile(t *testing.T) {
+	var decls []ast.Decl
+	for _, src := range []string{
+		"func add(x, y int32) int32 {\nreturn x + y\n}",
+		"func put(int32, ...int32) {\n}",
+		"func got(want int32) int32 {\nreturn want\n}",
+		"func Add() {\n}",
+		"func main() {\n}",
+	} {
+		f, err := parseTestFunc(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decls = append(decls, f)
+	}
+	file := &ast.File{Name: ast.NewIdent("p"), Decls: decls}
+	fset := token.NewFileSet()
+	testFile := CreateTestFile(fset, file)
+	buf := new(bytes.Buffer)
+	if err := format.Node(buf, fset, testFile); err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAdd(t *testing.T) {
+	// TODO: Add test cases.
+	golden := []struct {
+		x    int32
+		y    int32
+		want int32
+	}{{}}
+	for _, g := range golden {
+		got := add(g.x, g.y)
+		if !reflect.DeepEqual(got, g.want) {
+			t.Errorf("add(%v, %v): expected %v, got %v", g.x, g.y, g.want, got)
+		}
+	}
+}
+
+func TestPut(t *testing.T) {
+	// TODO: Add test cases.
+	golden := []struct {
+		arg0 int32
+	}{{}}
+	for _, g := range golden {
+		put(g.arg0)
+	}
+}
+
+func TestGot(t *testing.T) {
+	// TODO: Add test cases.
+	golden := []struct {
+		want  int32
+		want_ int32
+	}{{}}
+	for _, g := range golden {
+		got_ := got(g.want)
+		if !reflect.DeepEqual(got_, g.want_) {
+			t.Errorf("got(%v): expected %v, got %v", g.want, g.want_, got_)
+		}
+	}
+}
+
+func TestAdd_1(t *testing.T) {
+	Add()
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output mismatch; expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,400 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	lltoken "github.com/llir/llvm/asm/token"
+	"github.com/mewkiz/pkg/errutil"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+// parseType converts the LLVM IR type at the start of the provided tokens into
+// an equivalent Go type expression. The number of tokens consumed by the type is
+// returned in n.
+//
+// Syntax:
+//    i32
+//    i32*
+//    [4 x i32]
+//    <4 x i32>
+//    { i32, i8* }
+//    %struct.foo
+func parseType(tokens []lltoken.Token) (typ ast.Expr, n int, err error) {
+	if len(tokens) < 1 {
+		return nil, 0, errutil.New("unable to parse type; expected >= 1 tokens, got 0")
+	}
+	switch tok := tokens[0]; tok.Kind {
+	case lltoken.Type:
+		//    i32
+		typ, err = getType(tok)
+		if err != nil {
+			return nil, 0, errutil.Err(err)
+		}
+		n = 1
+	case lltoken.Lbrack, lltoken.Less:
+		//    [4 x i32]
+		//    <4 x i32>
+		end := lltoken.Rbrack
+		if tok.Kind == lltoken.Less {
+			end = lltoken.Greater
+		}
+		if len(tokens) < 5 {
+			return nil, 0, errutil.Newf("unable to parse array type; expected >= 5 tokens, got %d", len(tokens))
+		}
+		if tokens[1].Kind != lltoken.Int {
+			return nil, 0, errutil.Newf("invalid array type; expected array length, got %q", tokens[1])
+		}
+		if tokens[2].Kind != lltoken.KwX {
+			return nil, 0, errutil.Newf(`invalid array type; expected "x" token, got %q`, tokens[2])
+		}
+		elem, m, err := parseType(tokens[3:])
+		if err != nil {
+			return nil, 0, errutil.Err(err)
+		}
+		n = 3 + m
+		if n >= len(tokens) || tokens[n].Kind != end {
+			return nil, 0, errutil.Newf("invalid array type; expected %v token", end)
+		}
+		n++
+		typ = &ast.ArrayType{
+			Len: &ast.BasicLit{Kind: token.INT, Value: tokens[1].Val},
+			Elt: elem,
+		}
+	case lltoken.Lbrace:
+		//    { i32, i8* }
+		fields := &ast.FieldList{}
+		n = 1
+		for n < len(tokens) && tokens[n].Kind != lltoken.Rbrace {
+			if len(fields.List) > 0 {
+				if tokens[n].Kind != lltoken.Comma {
+					return nil, 0, errutil.Newf(`invalid struct type; expected "," token, got %q`, tokens[n])
+				}
+				n++
+			}
+			field, m, err := parseType(tokens[n:])
+			if err != nil {
+				return nil, 0, errutil.Err(err)
+			}
+			n += m
+			fields.List = append(fields.List, &ast.Field{
+				Names: []*ast.Ident{getFieldName(len(fields.List))},
+				Type:  field,
+			})
+		}
+		if n >= len(tokens) {
+			return nil, 0, errutil.New(`invalid struct type; expected "}" token`)
+		}
+		n++
+		typ = &ast.StructType{Fields: fields}
+	case lltoken.LocalVar:
+		//    %struct.foo
+		typ = newIdent(strings.TrimPrefix(tok.Val, "struct."))
+		n = 1
+	default:
+		return nil, 0, errutil.Newf("support for LLVM IR type token kind %v not yet implemented", tok.Kind)
+	}
+
+	// Parse pointer types.
+	//    i32**
+	for n < len(tokens) && tokens[n].Kind == lltoken.Star {
+		typ = &ast.StarExpr{X: typ}
+		n++
+	}
+	return typ, n, nil
+}
+
+// getGoType converts the provided LLVM IR type into an equivalent Go type
+// expression.
+//
+//    i1           -> bool
+//    i8           -> int8
+//    i24          -> int32 (rounded up)
+//    float        -> float32
+//    double       -> float64
+//    i32*         -> *int32
+//    [4 x i32]    -> [4]int32
+//    <4 x i32>    -> [4]int32
+//    { i32, i8 }  -> struct { _0 int32; _1 int8 }
+//    %struct.foo  -> foo
+//    i32 (i8*)    -> func(*int8) int32
+func getGoType(typ llvm.Type) (ast.Expr, error) {
+	switch kind := typ.TypeKind(); kind {
+	case llvm.IntegerTypeKind:
+		//    i32
+		return getIntType(typ.IntTypeWidth(), true)
+	case llvm.FloatTypeKind:
+		//    float
+		return newIdent("float32"), nil
+	case llvm.DoubleTypeKind:
+		//    double
+		return newIdent("float64"), nil
+	case llvm.PointerTypeKind:
+		//    i32*
+		elem, err := getGoType(typ.ElementType())
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		return &ast.StarExpr{X: elem}, nil
+	case llvm.ArrayTypeKind, llvm.VectorTypeKind:
+		//    [4 x i32]
+		//    <4 x i32>
+		elem, err := getGoType(typ.ElementType())
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		n := typ.ArrayLength()
+		if kind == llvm.VectorTypeKind {
+			n = typ.VectorSize()
+		}
+		length := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(n)}
+		return &ast.ArrayType{Len: length, Elt: elem}, nil
+	case llvm.StructTypeKind:
+		//    %struct.foo
+		if name := typ.StructName(); len(name) > 0 {
+			return newIdent(strings.TrimPrefix(name, "struct.")), nil
+		}
+		//    { i32, i8 }
+		return getStructType(typ)
+	case llvm.FunctionTypeKind:
+		//    i32 (i8*)
+		sig := &ast.FuncType{
+			Params: &ast.FieldList{},
+		}
+		for _, param := range typ.ParamTypes() {
+			field, err := getGoType(param)
+			if err != nil {
+				return nil, errutil.Err(err)
+			}
+			sig.Params.List = append(sig.Params.List, &ast.Field{Type: field})
+		}
+		if ret := typ.ReturnType(); ret.TypeKind() != llvm.VoidTypeKind {
+			result, err := getGoType(ret)
+			if err != nil {
+				return nil, errutil.Err(err)
+			}
+			sig.Results = &ast.FieldList{List: []*ast.Field{{Type: result}}}
+		}
+		return sig, nil
+	default:
+		return nil, errutil.Newf("support for LLVM IR type kind %d not yet implemented", int(kind))
+	}
+}
+
+// getZeroValue returns the Go expression of the zero value of the provided
+// LLVM IR type.
+//
+//    i1          ; false
+//    i32         ; 0
+//    double      ; 0
+//    i32*        ; nil
+//    [4 x i32]   ; [4]int32{}
+//    %struct.foo ; foo{}
+func getZeroValue(typ llvm.Type) (ast.Expr, error) {
+	switch kind := typ.TypeKind(); kind {
+	case llvm.IntegerTypeKind:
+		if typ.IntTypeWidth() == 1 {
+			return newIdent("false"), nil
+		}
+		return &ast.BasicLit{Kind: token.INT, Value: "0"}, nil
+	case llvm.FloatTypeKind, llvm.DoubleTypeKind:
+		return &ast.BasicLit{Kind: token.INT, Value: "0"}, nil
+	case llvm.PointerTypeKind, llvm.FunctionTypeKind:
+		return newIdent("nil"), nil
+	case llvm.ArrayTypeKind, llvm.VectorTypeKind, llvm.StructTypeKind:
+		goType, err := getGoType(typ)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		return &ast.CompositeLit{Type: goType}, nil
+	default:
+		return nil, errutil.Newf("support for zero value of LLVM IR type kind %d not yet implemented", int(kind))
+	}
+}
+
+// getStructType converts the body of the provided LLVM IR structure type into
+// an equivalent Go struct type, regardless of whether the structure type is
+// named.
+//
+//    { i32, i8 }  -> struct { _0 int32; _1 int8 }
+func getStructType(typ llvm.Type) (*ast.StructType, error) {
+	fields := &ast.FieldList{}
+	for i, elem := range typ.StructElementTypes() {
+		field, err := getGoType(elem)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		fields.List = append(fields.List, &ast.Field{
+			Names: []*ast.Ident{getFieldName(i)},
+			Type:  field,
+		})
+	}
+	return &ast.StructType{Fields: fields}, nil
+}
+
+// parseTypes returns the Go type declarations of the named structure types
+// referenced by the global variables and the provided functions of the LLVM IR
+// module. Each named structure type is declared once, even if referenced by
+// several functions, and the declarations are sorted by LLVM type name.
+//
+//    %struct.foo = type { i32, i8 } ; type foo struct { _0 int32; _1 int8 }
+func parseTypes(module llvm.Module, funcNames []string) ([]ast.Decl, error) {
+	types := make(map[string]llvm.Type)
+	for g := module.FirstGlobal(); !g.IsNil(); g = llvm.NextGlobal(g) {
+		collectTypes(types, g.Type())
+	}
+	for _, funcName := range funcNames {
+		llFunc := module.NamedFunction(funcName)
+		if llFunc.IsNil() {
+			continue
+		}
+		collectTypes(types, llFunc.Type())
+		for _, bb := range llFunc.BasicBlocks() {
+			for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+				collectTypes(types, inst.Type())
+				for i := 0; i < inst.OperandsCount(); i++ {
+					collectTypes(types, inst.Operand(i).Type())
+				}
+			}
+		}
+	}
+
+	var names []string
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var decls []ast.Decl
+	for _, name := range names {
+		typ, err := getStructType(types[name])
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		spec := &ast.TypeSpec{
+			Name: newIdent(strings.TrimPrefix(name, "struct.")),
+			Type: typ,
+		}
+		decls = append(decls, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{spec}})
+	}
+	return decls, nil
+}
+
+// collectTypes adds the named structure types referenced by the provided LLVM
+// IR type to types, which maps from LLVM type name to structure type.
+func collectTypes(types map[string]llvm.Type, typ llvm.Type) {
+	switch typ.TypeKind() {
+	case llvm.PointerTypeKind, llvm.ArrayTypeKind, llvm.VectorTypeKind:
+		collectTypes(types, typ.ElementType())
+	case llvm.StructTypeKind:
+		if name := typ.StructName(); len(name) > 0 {
+			if _, ok := types[name]; ok {
+				// Already collected; also terminates recursive types.
+				return
+			}
+			types[name] = typ
+		}
+		for _, elem := range typ.StructElementTypes() {
+			collectTypes(types, elem)
+		}
+	case llvm.FunctionTypeKind:
+		collectTypes(types, typ.ReturnType())
+		for _, param := range typ.ParamTypes() {
+			collectTypes(types, param)
+		}
+	}
+}
+
+// getType converts the provided LLVM IR type token into an equivalent Go type
+// identifier.
+func getType(tok lltoken.Token) (*ast.Ident, error) {
+	switch tok.Val {
+	case "float":
+		return newIdent("float32"), nil
+	case "double":
+		return newIdent("float64"), nil
+	}
+	width, err := getIntWidth(tok)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return getIntType(width, true)
+}
+
+// getIntWidth returns the bit width of the provided LLVM IR integer type token
+// (e.g. 32 for "i32").
+func getIntWidth(tok lltoken.Token) (int, error) {
+	if !strings.HasPrefix(tok.Val, "i") {
+		return 0, errutil.Newf("support for LLVM IR type %q not yet implemented", tok.Val)
+	}
+	width, err := strconv.Atoi(tok.Val[1:])
+	if err != nil {
+		return 0, errutil.Newf("support for LLVM IR type %q not yet implemented", tok.Val)
+	}
+	return width, nil
+}
+
+// getIntType returns the signed or unsigned Go integer type identifier of the
+// given bit width. Bit widths which have no Go equivalent (e.g. i24) are rounded
+// up to the next Go integer type, and a warning is logged since values of the
+// type are truncated by wrapInt.
+func getIntType(width int, signed bool) (*ast.Ident, error) {
+	if width == 1 && signed {
+		return newIdent("bool"), nil
+	}
+	goWidth := getGoIntWidth(width)
+	if goWidth == 0 {
+		return nil, errutil.Newf("support for LLVM IR integer type i%d not yet implemented", width)
+	}
+	name := "int" + strconv.Itoa(goWidth)
+	if !signed {
+		name = "u" + name
+	}
+	if width != 1 && width != goWidth {
+		Logger.Printf("Rounding i%d up to %s; values are truncated to %d bits.\n", width, name, width)
+	}
+	return newIdent(name), nil
+}
+
+// getGoIntWidth returns the bit width of the Go integer type of the given LLVM
+// IR integer bit width (e.g. 32 for i24), or 0 if not supported.
+func getGoIntWidth(width int) int {
+	switch {
+	case width >= 1 && width <= 8:
+		return 8
+	case width > 8 && width <= 16:
+		return 16
+	case width > 16 && width <= 32:
+		return 32
+	case width > 32 && width <= 64:
+		return 64
+	}
+	return 0
+}
+
+// wrapInt returns an expression which wraps the provided signed integer
+// expression around to the given bit width, if the bit width has no Go
+// equivalent; and x otherwise. Values of such bit widths (e.g. i24) are stored
+// in the next Go integer type (e.g. int32), sign-extended from the bit width.
+//
+//    i24 %x ; x << 8 >> 8
+func wrapInt(x ast.Expr, width int) ast.Expr {
+	goWidth := getGoIntWidth(width)
+	if width == 1 || width == goWidth || goWidth == 0 {
+		return x
+	}
+	if _, ok := x.(*ast.BinaryExpr); ok {
+		x = &ast.ParenExpr{X: x}
+	}
+	shift := &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(goWidth - width)}
+	shl := &ast.BinaryExpr{X: x, Op: token.SHL, Y: shift}
+	return &ast.BinaryExpr{X: shl, Op: token.SHR, Y: shift}
+}
+
+// getFieldName returns the Go identifier of the struct field at the given
+// index (e.g. "_0").
+func getFieldName(index int) *ast.Ident {
+	return newIdent("_" + strconv.Itoa(index))
+}
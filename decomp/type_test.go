@@ -0,0 +1,65 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+)
+
+func TestGetIntType(t *testing.T) {
+	golden := []struct {
+		width  int
+		signed bool
+		want   string
+		err    bool
+	}{
+		{width: 1, signed: true, want: "bool"},
+		{width: 1, signed: false, want: "uint8"},
+		{width: 8, signed: true, want: "int8"},
+		{width: 8, signed: false, want: "uint8"},
+		{width: 16, signed: true, want: "int16"},
+		{width: 24, signed: true, want: "int32"},
+		{width: 24, signed: false, want: "uint32"},
+		{width: 32, signed: true, want: "int32"},
+		{width: 33, signed: true, want: "int64"},
+		{width: 64, signed: false, want: "uint64"},
+		{width: 0, signed: true, err: true},
+		{width: 128, signed: true, err: true},
+	}
+	for _, g := range golden {
+		got, err := getIntType(g.width, g.signed)
+		if g.err {
+			if err == nil {
+				t.Errorf("i%d (signed=%v): expected error, got nil", g.width, g.signed)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("i%d (signed=%v): unexpected error: %v", g.width, g.signed, err)
+			continue
+		}
+		if got.Name != g.want {
+			t.Errorf("i%d (signed=%v): type mismatch; expected %q, got %q", g.width, g.signed, g.want, got.Name)
+		}
+	}
+}
+
+func TestWrapInt(t *testing.T) {
+	golden := []struct {
+		x     ast.Expr
+		width int
+		want  string
+	}{
+		{x: ast.NewIdent("x"), width: 32, want: "x"},
+		{x: ast.NewIdent("x"), width: 1, want: "x"},
+		{x: ast.NewIdent("x"), width: 24, want: "x << 8 >> 8"},
+		{x: ast.NewIdent("x"), width: 4, want: "x << 4 >> 4"},
+		{x: ast.NewIdent("x"), width: 48, want: "x << 16 >> 16"},
+		{x: &ast.BinaryExpr{X: ast.NewIdent("x"), Op: token.ADD, Y: ast.NewIdent("y")}, width: 24, want: "(x + y) << 8 >> 8"},
+	}
+	for _, g := range golden {
+		if got := sprintNode(wrapInt(g.x, g.width)); got != g.want {
+			t.Errorf("i%d: expression mismatch; expected %q, got %q", g.width, g.want, got)
+		}
+	}
+}
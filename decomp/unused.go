@@ -0,0 +1,175 @@
+package decomp
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// removeUnusedAssigns removes the assignments to local variables which are
+// never used, as Go rejects variables which are declared but not used. The
+// assignments are removed if their right-hand side is free of side effects, and
+// assigned to the blank identifier otherwise. Removing an assignment may leave
+// other variables unused, so the process is repeated until no unused variables
+// remain.
+//
+//    // from:
+//    _2 := x + 1 // unused
+//    _3 := foo() // unused
+//
+//    // to:
+//    _ = foo()
+func removeUnusedAssigns(body *ast.BlockStmt) {
+	for {
+		unused := findUnusedVars(body)
+		if len(unused) == 0 {
+			return
+		}
+		changed := false
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BlockStmt:
+				n.List = removeDeadAssigns(n.List, unused, &changed)
+			case *ast.CaseClause:
+				n.Body = removeDeadAssigns(n.Body, unused, &changed)
+			case *ast.LabeledStmt:
+				// Labeled statements must be kept, as they may be the target of
+				// goto statements.
+				if assign, ok := getDeadAssign(n.Stmt, unused); ok {
+					n.Stmt = discardAssign(assign)
+					changed = true
+				}
+			}
+			return true
+		})
+		if !changed {
+			return
+		}
+	}
+}
+
+// findUnusedVars returns the names of the local variables of the function body
+// which are defined but never read.
+func findUnusedVars(body *ast.BlockStmt) map[string]bool {
+	defined := make(map[string]bool)
+	writes := make(map[*ast.Ident]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok {
+			for _, lhs := range assign.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					writes[ident] = true
+					if assign.Tok == token.DEFINE {
+						defined[ident.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	read := make(map[string]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && !writes[ident] {
+			read[ident.Name] = true
+		}
+		return true
+	})
+	unused := make(map[string]bool)
+	for name := range defined {
+		if name != "_" && !read[name] {
+			unused[name] = true
+		}
+	}
+	return unused
+}
+
+// removeDeadAssigns removes the assignments to unused variables from the
+// provided statement list, or assigns their right-hand side to the blank
+// identifier if it has side effects. The changed flag is set if any statement
+// was removed or rewritten.
+func removeDeadAssigns(stmts []ast.Stmt, unused map[string]bool, changed *bool) []ast.Stmt {
+	var list []ast.Stmt
+	for _, stmt := range stmts {
+		assign, ok := getDeadAssign(stmt, unused)
+		if !ok {
+			list = append(list, stmt)
+			continue
+		}
+		*changed = true
+		if !isPure(assign.Rhs[0]) {
+			list = append(list, discardAssign(assign))
+		}
+	}
+	return list
+}
+
+// getDeadAssign returns the provided statement as an assignment to an unused
+// variable, and a boolean indicating success.
+func getDeadAssign(stmt ast.Stmt, unused map[string]bool) (*ast.AssignStmt, bool) {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return nil, false
+	}
+	ident, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok || !unused[ident.Name] {
+		return nil, false
+	}
+	return assign, true
+}
+
+// discardAssign rewrites the provided assignment to assign its right-hand side
+// to the blank identifier, and returns it. An empty statement is returned if
+// the right-hand side has no side effects.
+//
+//    _3 := foo() // _ = foo()
+func discardAssign(assign *ast.AssignStmt) ast.Stmt {
+	if isPure(assign.Rhs[0]) {
+		return &ast.EmptyStmt{}
+	}
+	assign.Lhs = []ast.Expr{ast.NewIdent("_")}
+	assign.Tok = token.ASSIGN
+	return assign
+}
+
+// isPure returns true if the evaluation of the provided expression has no side
+// effects (other than run-time panics), and false otherwise. Calls are impure,
+// except for conversions to predeclared types and calls to pure builtin
+// functions.
+func isPure(expr ast.Expr) bool {
+	pure := true
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if fun, ok := n.Fun.(*ast.Ident); !ok || !pureFuncs[fun.Name] {
+				pure = false
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				pure = false
+			}
+		case *ast.FuncLit:
+			pure = false
+		}
+		return pure
+	})
+	return pure
+}
+
+// pureFuncs specifies the predeclared types and builtin functions which may be
+// called without side effects.
+var pureFuncs = map[string]bool{
+	"bool":    true,
+	"int8":    true,
+	"int16":   true,
+	"int32":   true,
+	"int64":   true,
+	"uint8":   true,
+	"uint16":  true,
+	"uint32":  true,
+	"uint64":  true,
+	"uintptr": true,
+	"float32": true,
+	"float64": true,
+	"string":  true,
+	"len":     true,
+	"cap":     true,
+	"new":     true,
+}
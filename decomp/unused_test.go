@@ -0,0 +1,80 @@
+package decomp
+
+import (
+	"go/parser"
+	"testing"
+)
+
+func TestRemoveUnusedAssigns(t *testing.T) {
+	golden := []struct {
+		src  string
+		want string
+	}{
+		// Pure and impure right-hand sides.
+		{
+			src:  "func f(x int32) {\n_2 := x + 1\n_3 := foo()\n}",
+			want: "func f(x int32) {\n\t_ = foo()\n}",
+		},
+		// Variables which become unused once other assignments are removed.
+		{
+			src:  "func f(x int32) {\n_2 := x + 1\n_3 := _2 * 2\n_4 := _3 - 1\n}",
+			want: "func f(x int32) {\n}",
+		},
+		// Used variables, and assignments to declared variables.
+		{
+			src:  "func f(x int32) int32 {\n_2 := x + 1\nx = 3\nreturn _2\n}",
+			want: "func f(x int32) int32 {\n\t_2 := x + 1\n\tx = 3\n\treturn _2\n}",
+		},
+		// Labeled assignments are kept as the target of goto statements.
+		{
+			src:  "func f(x int32) {\nbb1:\n_2 := x + 1\nbb2:\n_3 := foo()\ngoto bb1\ngoto bb2\n}",
+			want: "func f(x int32) {\nbb1:\n\t;\nbb2:\n\t_ = foo()\n\tgoto bb1\n\tgoto bb2\n}",
+		},
+		// Nested statements.
+		{
+			src:  "func f(x int32) {\nswitch x {\ncase 1:\n_2 := x + 1\n}\n}",
+			want: "func f(x int32) {\n\tswitch x {\n\tcase 1:\n\t}\n}",
+		},
+	}
+	for i, g := range golden {
+		f, err := parseTestFunc(g.src)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		removeUnusedAssigns(f.Body)
+		if got := sprintNode(f); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+func TestIsPure(t *testing.T) {
+	golden := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "x + 1", want: true},
+		{expr: "*p", want: true},
+		{expr: "a[i].f", want: true},
+		{expr: "int32(x) << 2", want: true},
+		{expr: "len(s) + cap(s)", want: true},
+		{expr: "new(int32)", want: true},
+		{expr: "foo()", want: false},
+		{expr: "x + foo()", want: false},
+		{expr: "int32(foo())", want: false},
+		{expr: "p.f()", want: false},
+		{expr: "<-c", want: false},
+		{expr: "func() {}", want: false},
+	}
+	for i, g := range golden {
+		expr, err := parser.ParseExpr(g.expr)
+		if err != nil {
+			t.Errorf("i=%d: %v", i, err)
+			continue
+		}
+		if got := isPure(expr); got != g.want {
+			t.Errorf("i=%d: isPure(%q) mismatch; expected %v, got %v", i, g.expr, g.want, got)
+		}
+	}
+}
@@ -9,21 +9,24 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/printer"
 	"go/token"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
-	"path"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"time"
 
-	xprimitive "decomp.org/x/graphs/primitive"
-	"github.com/mewfork/dot"
+	"decomp.org/x/cmd/ll2go/decomp"
 	"github.com/mewkiz/pkg/errutil"
 	"github.com/mewkiz/pkg/osutil"
 	"github.com/mewkiz/pkg/pathutil"
@@ -31,25 +34,110 @@ import (
 )
 
 var (
+	// When flagComments is true, annotate the generated statements with the
+	// LLVM IR instructions they originate from.
+	flagComments bool
+	// When flagDot is true, store the control flow graph of each function as a
+	// DOT file (e.g. foo_graphs/bar.dot).
+	flagDot bool
+	// flagEmit specifies the output format; either "go" for Go source code or
+	// "ast" for the Go AST serialized as JSON.
+	flagEmit string
+	// When flagEmitTests is true, store a companion Go test file with a
+	// table-driven test stub for each decompiled function (e.g. foo_test.go).
+	flagEmitTests bool
+	// When flagFences is true, mark fence instructions with a comment;
+	// otherwise they are dropped.
+	flagFences bool
 	// When flagForce is true, force overwrite existing Go source code.
 	flagForce bool
 	// flagFuncs specifies a comma separated list of functions to decompile (e.g.
-	// "foo,bar").
+	// "foo,bar"). A leading "!" or "-" excludes the listed functions instead
+	// (e.g. "!foo,bar").
 	flagFuncs string
+	// When flagGofmt is true, format the Go source code using gofmt style.
+	flagGofmt bool
+	// When flagGoto is true, emit goto statements for unstructured control flow.
+	flagGoto bool
+	// When flagIgnoreUnwind is true, translate invoke instructions into calls
+	// followed by a branch to their normal destination.
+	flagIgnoreUnwind bool
+	// flagIndent specifies the number of spaces per indentation level of the Go
+	// source code if non-zero; otherwise tabs are used.
+	flagIndent int
+	// flagJobs specifies the number of input files to decompile in parallel.
+	flagJobs int
+	// When flagKeepGoing is true, continue decompiling the remaining functions
+	// after a function fails to decompile.
+	flagKeepGoing bool
+	// When flagKeepDot is true, keep the control flow graphs directory (e.g.
+	// foo_graphs) after decompilation.
+	flagKeepDot bool
+	// flagLocalPrefix specifies the prefix of the Go identifiers of local
+	// variable IDs (e.g. "_" for "%42" => "_42").
+	flagLocalPrefix string
+	// flagMainWrap specifies the name of a function which is called by a
+	// generated main function if non-empty.
+	flagMainWrap string
+	// When flagDryRun is true, print the Go source code to standard output
+	// without writing any files.
+	flagDryRun bool
+	// flagOutput specifies the output path if non-empty; "-" denotes standard
+	// output.
+	flagOutput string
 	// flagPkgName specifies the package name if non-empty.
 	flagPkgName string
+	// flagPtr specifies the pointer model of alloca instructions; either "value"
+	// or "pointer".
+	flagPtr string
 	// When flagQuiet is true, suppress non-error messages.
 	flagQuiet bool
+	// When flagSkipEH is true, emit stubs for functions which make use of
+	// exception handling.
+	flagSkipEH bool
+	// flagTimeout specifies the maximum duration of the decompilation of each
+	// function if non-zero.
+	flagTimeout time.Duration
+	// flagTmpDir specifies the directory of temporary files if non-empty;
+	// otherwise the default directory for temporary files is used.
+	flagTmpDir string
+	// When flagUnsafe is true, use unsafe.Pointer conversions for pointer casts.
+	flagUnsafe bool
 	// When flagQuiet is true, enable verbose output.
 	flagVerbose bool
+	// When flagZeroInit is true, explicitly initialize local variables to their
+	// zero value.
+	flagZeroInit bool
 )
 
 func init() {
+	flag.BoolVar(&flagComments, "comments", false, "Annotate statements with their LLVM IR instructions.")
+	flag.BoolVar(&flagDot, "dot", false, "Store control flow graphs as DOT files.")
+	flag.StringVar(&flagEmit, "emit", "go", `Output format ("go" or "ast").`)
+	flag.BoolVar(&flagEmitTests, "emit-tests", false, `Store test stubs of the decompiled functions in a companion test file (e.g. "foo_test.go").`)
 	flag.BoolVar(&flagForce, "f", false, "Force overwrite existing Go source code.")
-	flag.StringVar(&flagFuncs, "funcs", "", `Comma separated list of functions to decompile (e.g. "foo,bar").`)
+	flag.BoolVar(&flagFences, "fences", false, "Mark fence instructions (memory barriers) with a comment rather than dropping them.")
+	flag.StringVar(&flagFuncs, "funcs", "", `Comma separated list of functions to decompile (e.g. "foo,bar"), or to exclude if prefixed by "!" or "-" (e.g. "!foo,bar").`)
+	flag.BoolVar(&flagGofmt, "gofmt", true, "Format the Go source code using gofmt style.")
+	flag.BoolVar(&flagGoto, "goto", false, "Emit goto statements for unstructured control flow.")
+	flag.BoolVar(&flagIgnoreUnwind, "ignore-unwind", false, "Translate invoke instructions into calls, ignoring their unwind destination.")
+	flag.IntVar(&flagIndent, "indent", 0, "Number of spaces per indentation level; tabs are used if 0.")
+	flag.IntVar(&flagJobs, "j", 1, "Number of input files to decompile in parallel.")
+	flag.BoolVar(&flagKeepGoing, "k", false, "Keep going; emit stubs for functions which fail to decompile.")
+	flag.BoolVar(&flagKeepDot, "keepdot", false, `Keep the control flow graphs directory (e.g. "foo_graphs") after decompilation.`)
+	flag.StringVar(&flagLocalPrefix, "localprefix", "_", `Prefix of the Go identifiers of local variable IDs (e.g. "v" for "%42" => "v42").`)
+	flag.StringVar(&flagMainWrap, "mainwrap", "", "Generate a main function which calls the named function with zero-value arguments and prints its result.")
+	flag.BoolVar(&flagDryRun, "n", false, "Dry run; print the Go source code to stdout without writing any files.")
+	flag.StringVar(&flagOutput, "o", "", `Output path ("-" for stdout).`)
 	flag.StringVar(&flagPkgName, "pkgname", "", "Package name.")
+	flag.StringVar(&flagPtr, "ptr", decomp.PtrPointer, `Pointer model of alloca instructions ("value" or "pointer").`)
 	flag.BoolVar(&flagQuiet, "q", false, "Suppress non-error messages.")
+	flag.BoolVar(&flagSkipEH, "skip-eh", false, "Emit stubs for functions which make use of exception handling.")
+	flag.DurationVar(&flagTimeout, "timeout", 0, `Maximum duration of the decompilation of each function (e.g. "30s"); 0 disables the timeout.`)
+	flag.StringVar(&flagTmpDir, "tmpdir", "", "Directory of temporary files.")
+	flag.BoolVar(&flagUnsafe, "unsafe", false, "Use unsafe.Pointer conversions for pointer casts.")
 	flag.BoolVar(&flagVerbose, "v", false, "Enable verbose output.")
+	flag.BoolVar(&flagZeroInit, "zeroinit", false, "Explicitly initialize local variables to their zero value.")
 	flag.Usage = usage
 }
 
@@ -64,255 +152,235 @@ func usage() {
 	flag.PrintDefaults()
 }
 
+// opts specifies the decompiler options, as specified by the command line
+// flags.
+var opts decomp.Options
+
 func main() {
 	flag.Parse()
+	if !flagQuiet {
+		decomp.Logger.SetOutput(os.Stderr)
+		if flagVerbose {
+			decomp.Debug.SetOutput(os.Stderr)
+		}
+	}
 	if flag.NArg() < 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
-	for _, llPath := range flag.Args() {
-		err := ll2go(llPath)
-		if err != nil {
-			log.Fatalln(err)
-		}
+	if len(flagOutput) > 0 && flagOutput != "-" && flag.NArg() > 1 {
+		log.Fatalf("unable to store %d Go source files to the single output path %q; use -o - to print them to stdout", flag.NArg(), flagOutput)
 	}
-}
-
-// ll2go parses the provided LLVM IR assembly file and decompiles it to Go
-// source code.
-func ll2go(llPath string) error {
-	// File name and file path without extension.
-	baseName := pathutil.FileName(llPath)
-	basePath := pathutil.TrimExt(llPath)
-
-	// TODO: Create graphs in /tmp/xxx_graphs/*.dot
-
-	// Create temporary foo.dot file, e.g.
-	//
-	//    foo.ll -> foo_graphs/*.dot
-	dotDir := basePath + "_graphs"
-	if ok, _ := osutil.Exists(dotDir); !ok {
-		if !flagQuiet {
-			log.Printf("Creating control flow graphs for %q.\n", filepath.Base(llPath))
-		}
-		cmd := exec.Command("ll2dot", "-q", "-funcs", flagFuncs, "-f", llPath)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		err := cmd.Run()
-		if err != nil {
-			return errutil.Err(err)
-		}
+	if flagPtr != decomp.PtrValue && flagPtr != decomp.PtrPointer {
+		log.Fatalf("invalid pointer model %q; expected %q or %q", flagPtr, decomp.PtrValue, decomp.PtrPointer)
 	}
-
-	// Create temporary foo.bc file, e.g.
-	//
-	//    foo.ll -> foo.bc
-	bcPath := fmt.Sprintf("/tmp/%s.bc", baseName)
-	cmd := exec.Command("llvm-as", "-o", bcPath, llPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err != nil {
-		return errutil.Err(err)
+	if flagEmit != "go" && flagEmit != "ast" {
+		log.Fatalf("invalid output format %q; expected %q or %q", flagEmit, "go", "ast")
 	}
-
-	// Remove temporary foo.bc file.
-	defer func() {
-		err = os.Remove(bcPath)
-		if err != nil {
-			log.Fatalln(errutil.Err(err))
-		}
-	}()
-
-	// Parse foo.bc
-	module, err := llvm.ParseBitcodeFile(bcPath)
-	if err != nil {
-		return errutil.Err(err)
+	if flagIndent < 0 {
+		log.Fatalf("invalid indentation %d; expected >= 0", flagIndent)
 	}
-	defer module.Dispose()
-
-	// Get function names.
-	var funcNames []string
-	if len(flagFuncs) > 0 {
-		// Get function names from command line flag:
-		//
-		//    -funcs="foo,bar"
-		funcNames = strings.Split(flagFuncs, ",")
-	} else {
-		// Get all function names.
-		for llFunc := module.FirstFunction(); !llFunc.IsNil(); llFunc = llvm.NextFunction(llFunc) {
-			if llFunc.IsDeclaration() {
-				// Ignore function declarations (e.g. functions without bodies).
-				continue
-			}
-			funcNames = append(funcNames, llFunc.Name())
-		}
+	if flagJobs < 1 {
+		log.Fatalf("invalid number of jobs %d; expected >= 1", flagJobs)
 	}
 
-	// Locate package name.
-	pkgName := flagPkgName
-	if len(flagPkgName) == 0 {
-		pkgName = baseName
-		for _, funcName := range funcNames {
-			if funcName == "main" {
-				pkgName = "main"
-				break
-			}
-		}
+	// Decompiler options.
+	opts = decomp.Options{
+		PkgName:      flagPkgName,
+		TmpDir:       flagTmpDir,
+		Dot:          flagDot,
+		Goto:         flagGoto,
+		KeepGoing:    flagKeepGoing,
+		KeepGraphs:   flagKeepDot,
+		Unsafe:       flagUnsafe,
+		Comments:     flagComments,
+		Fences:       flagFences,
+		Ptr:          flagPtr,
+		LocalPrefix:  flagLocalPrefix,
+		MainWrap:     flagMainWrap,
+		IgnoreUnwind: flagIgnoreUnwind,
+		SkipEH:       flagSkipEH,
+		Timeout:      flagTimeout,
+		ZeroInit:     flagZeroInit,
 	}
+	opts.Funcs, opts.ExcludeFuncs = parseFuncs(flagFuncs)
 
-	// Create foo.go.
-	file := &ast.File{
-		Name: newIdent(pkgName),
+	if !decompileFiles(flag.Args(), flagJobs) {
+		os.Exit(1)
 	}
+}
 
-	// TODO: Implement support for global variables.
-
-	// Parse each function.
-	for _, funcName := range funcNames {
-		if !flagQuiet {
-			log.Printf("Parsing function: %q\n", funcName)
-		}
-		graph, err := parseCFG(basePath, funcName)
-		if err != nil {
-			return errutil.Err(err)
-		}
+// parseFuncs parses the comma separated list of functions of the -funcs flag,
+// and returns the functions to decompile, or the functions to exclude if the
+// list is prefixed by "!" or "-".
+func parseFuncs(s string) (funcs, excludeFuncs []string) {
+	switch {
+	case len(s) == 0:
+		return nil, nil
+	case strings.HasPrefix(s, "!") || strings.HasPrefix(s, "-"):
+		//    -funcs="!foo,bar"
+		return nil, strings.Split(s[1:], ",")
+	}
+	//    -funcs="foo,bar"
+	return strings.Split(s, ","), nil
+}
 
-		// Structure the CFG.
-		dotDir := basePath + "_graphs"
-		dotName := funcName + ".dot"
-		dotPath := path.Join(dotDir, dotName)
-		jsonName := funcName + ".json"
-		jsonPath := path.Join(dotDir, jsonName)
-		if ok, _ := osutil.Exists(jsonPath); !ok {
-			cmd := exec.Command("restructure", "-o", jsonPath, dotPath)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			if !flagQuiet {
-				log.Printf("Structuring function: %q\n", funcName)
-			}
-			err = cmd.Run()
-			if err != nil {
-				return errutil.Err(err)
+// decompileFiles decompiles the provided LLVM IR assembly files using a pool of
+// the given number of workers. Errors are reported per file, without aborting
+// the remaining files. The boolean return value indicates success.
+func decompileFiles(llPaths []string, jobs int) bool {
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := true
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each worker reuses one LLVM context for its input files.
+			ctx := llvm.NewContext()
+			defer ctx.Dispose()
+			for llPath := range paths {
+				err := ll2go(ctx, llPath)
+				if err != nil {
+					log.Printf("unable to decompile %q: %v", llPath, err)
+					mu.Lock()
+					ok = false
+					mu.Unlock()
+				}
 			}
-		}
-		var hprims []*xprimitive.Primitive
-		fr, err := os.Open(jsonPath)
-		if err != nil {
-			return errutil.Err(err)
-		}
-		defer fr.Close()
-		dec := json.NewDecoder(fr)
-		err = dec.Decode(&hprims)
-		if err != nil {
-			return errutil.Err(err)
-		}
+		}()
+	}
+	for _, llPath := range llPaths {
+		paths <- llPath
+	}
+	close(paths)
+	wg.Wait()
+	return ok
+}
 
-		f, err := parseFunc(graph, module, funcName, hprims)
+// ll2go parses the provided LLVM IR assembly file into a module of the given
+// LLVM context and decompiles it to Go source code.
+func ll2go(ctx llvm.Context, llPath string) error {
+	opts := opts
+	// Positions of the comments of the Go source files.
+	fset := token.NewFileSet()
+	opts.Fset = fset
+	if flagDryRun {
+		// Store the control flow graphs and structuring results in a temporary
+		// directory, rather than next to the input file.
+		graphsDir, err := ioutil.TempDir(flagTmpDir, "ll2go")
 		if err != nil {
 			return errutil.Err(err)
 		}
-		file.Decls = append(file.Decls, f)
-		if flagVerbose && !flagQuiet {
-			printFunc(f)
-		}
+		defer os.RemoveAll(graphsDir)
+		opts.GraphsDir = graphsDir
 	}
 
-	// Store Go source code to file.
-	goPath := basePath + ".go"
-	if !flagQuiet {
-		log.Printf("Creating: %q\n", goPath)
+	// The Go source file is stored even if some functions failed to decompile
+	// in keep-going mode; the failures are reported afterwards.
+	file, err := decomp.DecompileFileContext(ctx, llPath, opts)
+	failures, ok := err.(decomp.Errors)
+	if err != nil && !ok {
+		return errutil.Err(err)
 	}
-	return storeFile(goPath, file)
-}
 
-// parseCFG parses the control flow graph of the function.
-//
-// For a source file "foo.ll" containing the functions "bar" and "baz" the
-// following DOT files will be created:
-//
-//    foo_graphs/bar.dot
-//    foo_graphs/baz.dot
-func parseCFG(basePath, funcName string) (graph *dot.Graph, err error) {
-	dotDir := basePath + "_graphs"
-	dotName := funcName + ".dot"
-	dotPath := fmt.Sprintf("%s/%s", dotDir, dotName)
-	return dot.ParseFile(dotPath)
-}
+	// Record the source file, ll2go version and flags.
+	file.Doc = newFileDoc(llPath)
 
-// parseFunc parses the given function and attempts to construct an equivalent
-// Go function declaration AST node.
-func parseFunc(graph *dot.Graph, module llvm.Module, funcName string, hprims []*xprimitive.Primitive) (*ast.FuncDecl, error) {
-	llFunc := module.NamedFunction(funcName)
-	if llFunc.IsNil() {
-		return nil, errutil.Newf("unable to locate function %q", funcName)
+	// Store Go source code to file.
+	goPath := pathutil.TrimExt(llPath) + ".go"
+	if flagEmit == "ast" {
+		goPath = pathutil.TrimExt(llPath) + ".json"
 	}
-	if llFunc.IsDeclaration() {
-		return nil, errutil.Newf("unable to create AST for %q; expected function definition, got function declaration (e.g. no body)", funcName)
+	if len(flagOutput) > 0 {
+		goPath = flagOutput
 	}
-
-	// Parse each basic block.
-	bbs := make(map[string]BasicBlock)
-	for _, llBB := range llFunc.BasicBlocks() {
-		bb, err := parseBasicBlock(llBB)
-		if err != nil {
-			return nil, err
-		}
-		bbs[bb.Name()] = bb
-		if flagVerbose && !flagQuiet {
-			printBB(bb)
-		}
+	if flagDryRun {
+		goPath = "-"
+	}
+	if goPath != "-" {
+		decomp.Logger.Printf("Creating: %q\n", goPath)
+	}
+	if err := storeFile(goPath, fset, file); err != nil {
+		return errutil.Err(err)
 	}
 
-	// Replace PHI instructions with assignment statements in the appropriate
-	// basic blocks.
-	for _, bb := range bbs {
-		block, ok := bb.(*basicBlock)
-		if !ok {
-			return nil, errutil.Newf("invalid basic block type; expected *basicBlock, got %T", bb)
+	// Store Go test stubs to file, e.g.
+	//
+	//    foo.go -> foo_test.go
+	if flagEmitTests {
+		testFile := decomp.CreateTestFile(fset, file)
+		// The test stubs are meant to be edited, contrary to the decompiled Go
+		// source code.
+		testFile.Doc = &ast.CommentGroup{
+			List: []*ast.Comment{
+				{Text: fmt.Sprintf("// Test stubs generated by ll2go from %s.", filepath.Base(llPath))},
+			},
 		}
-		for ident, defs := range block.phis {
-			for _, def := range defs {
-				assign := &ast.AssignStmt{
-					Lhs: []ast.Expr{newIdent(ident)},
-					Tok: token.ASSIGN,
-					Rhs: []ast.Expr{def.expr},
-				}
-				bbSrc := bbs[def.bb]
-				stmts := bbSrc.Stmts()
-				stmts = append(stmts, assign)
-				bbSrc.SetStmts(stmts)
-			}
+		testPath := goPath
+		if testPath != "-" {
+			ext := filepath.Ext(goPath)
+			testPath = strings.TrimSuffix(goPath, ext) + "_test" + ext
+			decomp.Logger.Printf("Creating: %q\n", testPath)
+		}
+		if err := storeFile(testPath, fset, testFile); err != nil {
+			return errutil.Err(err)
 		}
 	}
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
 
-	// Perform control flow analysis.
-	body, err := restructure(graph, bbs, hprims)
-	if err != nil {
-		return nil, errutil.Err(err)
+// newFileDoc returns a doc comment of the Go source file decompiled from the
+// provided LLVM IR assembly file, which records the source file, the version of
+// ll2go and the command line flags.
+//
+//	// Code generated by ll2go (devel) from foo.ll. DO NOT EDIT.
+//	//
+//	// Flags: -goto=true -ptr=value
+func newFileDoc(llPath string) *ast.CommentGroup {
+	version := "(unknown)"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
 	}
-	sig := &ast.FuncType{
-		Params: &ast.FieldList{},
+	doc := &ast.CommentGroup{
+		List: []*ast.Comment{
+			{Text: fmt.Sprintf("// Code generated by ll2go %s from %s. DO NOT EDIT.", version, filepath.Base(llPath))},
+		},
 	}
-	if funcName != "main" {
-		// TODO: Implement parsing of function signature.
+	var flags []string
+	flag.Visit(func(f *flag.Flag) {
+		flags = append(flags, fmt.Sprintf("-%s=%s", f.Name, f.Value))
+	})
+	if len(flags) > 0 {
+		doc.List = append(doc.List, &ast.Comment{Text: "//"}, &ast.Comment{Text: "// Flags: " + strings.Join(flags, " ")})
 	}
-	return createFunc(funcName, sig, body)
+	return doc
 }
 
-// createFunc creates and returns a Go function declaration based on the
-// provided function name, function signature and basic block.
-func createFunc(name string, sig *ast.FuncType, body *ast.BlockStmt) (*ast.FuncDecl, error) {
-	f := &ast.FuncDecl{
-		Name: newIdent(name),
-		Type: sig,
-		Body: body,
+// stdoutMu serializes writes to standard output.
+var stdoutMu sync.Mutex
+
+// storeFile stores the given Go source code to the provided file path. The Go
+// source code is printed to standard output if the file path is "-". The
+// positions of the Go source code are resolved using the given file set.
+func storeFile(goPath string, fset *token.FileSet, file *ast.File) error {
+	if goPath == "-" {
+		// Write the Go source code of each file in one piece, as files may be
+		// decompiled in parallel.
+		buf := new(bytes.Buffer)
+		if err := writeFile(buf, fset, file); err != nil {
+			return errutil.Err(err)
+		}
+		stdoutMu.Lock()
+		defer stdoutMu.Unlock()
+		_, err := buf.WriteTo(os.Stdout)
+		return err
 	}
-	return f, nil
-}
 
-// storeFile stores the given Go source code to the provided file path.
-func storeFile(goPath string, file *ast.File) error {
 	// Don't force overwrite Go output file.
 	if !flagForce {
 		if ok, _ := osutil.Exists(goPath); ok {
@@ -324,26 +392,46 @@ func storeFile(goPath string, file *ast.File) error {
 		return err
 	}
 	defer f.Close()
-	fset := token.NewFileSet()
-	return printer.Fprint(f, fset, file)
+	return writeFile(f, fset, file)
 }
 
-// printBB pretty-prints the basic block to stdout.
-func printBB(bb BasicBlock) {
-	fset := token.NewFileSet()
-	fmt.Printf("--- [ basic block %q ] ---\n", bb.Name())
-	printer.Fprint(os.Stdout, fset, bb.Stmts())
-	fmt.Println()
-	if term := bb.Term(); !term.IsNil() {
-		term.Dump()
+// writeFile writes the given Go source code to w, formatted using gofmt style if
+// the -gofmt flag is set, and indented using spaces if the -indent flag is set.
+// The Go AST is written as JSON instead if the -emit flag is "ast". The
+// positions of the Go source code are resolved using the given file set.
+func writeFile(w io.Writer, fset *token.FileSet, file *ast.File) error {
+	if flagEmit == "ast" {
+		return writeAST(w, file)
 	}
-	fmt.Println()
+	// The doc comment of the file is written separately, as the Go AST nodes
+	// have no positions to place it before the package clause.
+	if file.Doc != nil {
+		for _, c := range file.Doc.List {
+			if _, err := fmt.Fprintln(w, c.Text); err != nil {
+				return err
+			}
+		}
+		f := *file
+		f.Doc = nil
+		file = &f
+	}
+	if cfg := newPrinterConfig(); cfg != nil {
+		return cfg.Fprint(w, fset, file)
+	}
+	return format.Node(w, fset, file)
 }
 
-// printFunc pretty-prints the function to stdout.
-func printFunc(f *ast.FuncDecl) {
-	fset := token.NewFileSet()
-	fmt.Printf("--- [ function %q ] ---\n", f.Name)
-	printer.Fprint(os.Stdout, fset, f)
-	fmt.Println()
+// newPrinterConfig returns the printer configuration of the Go source code, as
+// specified by the -gofmt and -indent flags, or nil if the Go source code is
+// formatted using gofmt style.
+func newPrinterConfig() *printer.Config {
+	switch {
+	case flagIndent > 0:
+		//    -indent=4
+		return &printer.Config{Mode: printer.UseSpaces, Tabwidth: flagIndent}
+	case !flagGofmt:
+		//    -gofmt=false
+		return &printer.Config{Tabwidth: 8}
+	}
+	return nil
 }
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"decomp.org/x/cmd/ll2go/decomp"
+	"decomp.org/x/graphs/primitive"
+	"github.com/mewfork/dot"
+	"llvm.org/llvm/bindings/go/llvm"
+)
+
+func TestDecompileFilesParallel(t *testing.T) {
+	if _, err := exec.LookPath("restructure"); err != nil {
+		t.Skip("restructure not found in PATH")
+	}
+	buf, err := ioutil.ReadFile("examples/foo.ll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = decomp.Options{Ptr: decomp.PtrPointer}
+
+	// Decompile several copies of the input file, sequentially and in parallel.
+	const n = 8
+	var golden [n]string
+	for _, jobs := range []int{1, 4} {
+		dir, err := ioutil.TempDir("", "ll2go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		var llPaths []string
+		for i := 0; i < n; i++ {
+			llPath := filepath.Join(dir, fmt.Sprintf("foo%d.ll", i))
+			if err := ioutil.WriteFile(llPath, buf, 0644); err != nil {
+				t.Fatal(err)
+			}
+			llPaths = append(llPaths, llPath)
+		}
+		if !decompileFiles(llPaths, jobs) {
+			t.Fatalf("-j %d: unable to decompile files", jobs)
+		}
+		for i, llPath := range llPaths {
+			goPath := llPath[:len(llPath)-len(".ll")] + ".go"
+			got, err := ioutil.ReadFile(goPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The doc comment records the name of the input file.
+			got = bytes.Replace(got, []byte(filepath.Base(llPath)), []byte("foo.ll"), -1)
+			if jobs == 1 {
+				golden[i] = string(got)
+				continue
+			}
+			if string(got) != golden[i] {
+				t.Errorf("-j %d: %q mismatch; expected %q, got %q", jobs, goPath, golden[i], got)
+			}
+		}
+	}
+}
+
+func TestJSONNode(t *testing.T) {
+	const src = "package p\n\nfunc f(x int32) int32 {\n\treturn x\n}\n"
+	file, err := parser.ParseFile(token.NewFileSet(), "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := json.Marshal(jsonNode(reflect.ValueOf(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(buf)
+	for _, want := range []string{`"Node":"File"`, `"Node":"FuncDecl"`, `"Node":"Ident"`, `"Name":"x"`, `"Name":"int32"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q missing from %s", want, got)
+		}
+	}
+	// Positions, objects and scopes are elided.
+	for _, field := range []string{"Package", "NamePos", "Lbrace", "Return", "Obj", "Scope", "Unresolved"} {
+		if want := fmt.Sprintf("%q:", field); strings.Contains(got, want) {
+			t.Errorf("%q present in %s", want, got)
+		}
+	}
+}
+
+func TestWriteFileIndent(t *testing.T) {
+	const src = "package p\n\nfunc f(x int32) int32 {\n\tif x > 0 {\n\t\treturn x\n\t}\n\treturn 0\n}\n"
+	defer func(indent int, gofmt bool) {
+		flagIndent, flagGofmt = indent, gofmt
+	}(flagIndent, flagGofmt)
+	golden := []struct {
+		indent int
+		gofmt  bool
+		want   string
+	}{
+		// -indent=0
+		{
+			indent: 0,
+			gofmt:  true,
+			want:   src,
+		},
+		// -indent=4
+		{
+			indent: 4,
+			gofmt:  true,
+			want:   "package p\n\nfunc f(x int32) int32 {\n    if x > 0 {\n        return x\n    }\n    return 0\n}\n",
+		},
+		// -gofmt=false
+		{
+			indent: 0,
+			gofmt:  false,
+			want:   src,
+		},
+	}
+	for i, g := range golden {
+		flagIndent, flagGofmt = g.indent, g.gofmt
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := new(bytes.Buffer)
+		if err := writeFile(buf, fset, file); err != nil {
+			t.Errorf("i=%d: unexpected error; %v", i, err)
+			continue
+		}
+		if got := buf.String(); got != g.want {
+			t.Errorf("i=%d: output mismatch; expected %q, got %q", i, g.want, got)
+		}
+	}
+}
+
+func TestParseFuncs(t *testing.T) {
+	golden := []struct {
+		s            string
+		funcs        []string
+		excludeFuncs []string
+	}{
+		// -funcs=""
+		{s: ""},
+		// -funcs="foo"
+		{s: "foo", funcs: []string{"foo"}},
+		// -funcs="foo,bar"
+		{s: "foo,bar", funcs: []string{"foo", "bar"}},
+		// -funcs="!foo,bar"
+		{s: "!foo,bar", excludeFuncs: []string{"foo", "bar"}},
+		// -funcs="-foo"
+		{s: "-foo", excludeFuncs: []string{"foo"}},
+	}
+	for i, g := range golden {
+		funcs, excludeFuncs := parseFuncs(g.s)
+		if !reflect.DeepEqual(funcs, g.funcs) {
+			t.Errorf("i=%d: funcs mismatch; expected %q, got %q", i, g.funcs, funcs)
+		}
+		if !reflect.DeepEqual(excludeFuncs, g.excludeFuncs) {
+			t.Errorf("i=%d: excludeFuncs mismatch; expected %q, got %q", i, g.excludeFuncs, excludeFuncs)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	const src = `
+define i32 @f(i32 %x) {
+entry:
+  ret i32 %x
+}
+`
+	dir, err := ioutil.TempDir("", "ll2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	llPath := filepath.Join(dir, "foo.ll")
+	if err := ioutil.WriteFile(llPath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Temporary files are created in tmpDir, and must be removed.
+	tmpDir := filepath.Join(dir, "tmp")
+	if err := os.Mkdir(tmpDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Capture the Go source code printed to standard output.
+	stdout, err := ioutil.TempFile("", "ll2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stdout.Name())
+	defer stdout.Close()
+
+	defer func(dryRun, emitTests bool, tmpDir string, stdout *os.File) {
+		flagDryRun, flagEmitTests, flagTmpDir, os.Stdout = dryRun, emitTests, tmpDir, stdout
+	}(flagDryRun, flagEmitTests, flagTmpDir, os.Stdout)
+	flagDryRun, flagEmitTests, flagTmpDir, os.Stdout = true, true, tmpDir, stdout
+	opts = decomp.Options{
+		Structure: func(ctx context.Context, funcName string, graph *dot.Graph) ([]*primitive.Primitive, error) {
+			return nil, nil
+		},
+	}
+	defer func() { opts = decomp.Options{} }()
+
+	ctx := llvm.NewContext()
+	defer ctx.Dispose()
+	if err := ll2go(ctx, llPath); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir {
+			rel, _ := filepath.Rel(dir, path)
+			names = append(names, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"foo.ll", "tmp"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("files mismatch; expected %q, got %q", want, names)
+	}
+	buf, err := ioutil.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(buf)
+	for _, want := range []string{"func f(x int32) int32 {", "func TestF(t *testing.T) {"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q missing from standard output %q", want, got)
+		}
+	}
+}
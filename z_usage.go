@@ -3,12 +3,54 @@ Usage: ll2go [OPTION]... FILE...
 Decompile LLVM IR assembly files to Go source code (e.g. *.ll -> *.go).
 
 Flags:
+  -comments
+        Annotate statements with their LLVM IR instructions.
+  -dot
+        Store control flow graphs as DOT files.
+  -emit string
+        Output format ("go" or "ast"). (default "go")
+  -emit-tests
+        Store test stubs of the decompiled functions in a companion test file (e.g. "foo_test.go").
   -f    Force overwrite existing Go source code.
+  -fences
+        Mark fence instructions (memory barriers) with a comment rather than dropping them.
   -funcs string
-        Comma separated list of functions to decompile (e.g. "foo,bar").
+        Comma separated list of functions to decompile (e.g. "foo,bar"), or to exclude if prefixed by "!" or "-" (e.g. "!foo,bar").
+  -gofmt
+        Format the Go source code using gofmt style. (default true)
+  -goto
+        Emit goto statements for unstructured control flow.
+  -ignore-unwind
+        Translate invoke instructions into calls, ignoring their unwind destination.
+  -indent int
+        Number of spaces per indentation level; tabs are used if 0.
+  -j int
+        Number of input files to decompile in parallel. (default 1)
+  -k    Keep going; emit stubs for functions which fail to decompile.
+  -keepdot
+        Keep the control flow graphs directory (e.g. "foo_graphs") after decompilation.
+  -localprefix string
+        Prefix of the Go identifiers of local variable IDs (e.g. "v" for "%42" => "v42"). (default "_")
+  -mainwrap string
+        Generate a main function which calls the named function with zero-value arguments and prints its result.
+  -n    Dry run; print the Go source code to stdout without writing any files.
+  -o string
+        Output path ("-" for stdout).
   -pkgname string
         Package name.
+  -ptr string
+        Pointer model of alloca instructions ("value" or "pointer"). (default "pointer")
   -q    Suppress non-error messages.
+  -skip-eh
+        Emit stubs for functions which make use of exception handling.
+  -timeout duration
+        Maximum duration of the decompilation of each function (e.g. "30s"); 0 disables the timeout.
+  -tmpdir string
+        Directory of temporary files.
+  -unsafe
+        Use unsafe.Pointer conversions for pointer casts.
   -v    Enable verbose output.
+  -zeroinit
+        Explicitly initialize local variables to their zero value.
 */
 package main